@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	type item struct {
+		Name string
+		Tags []string
+	}
+
+	type config struct {
+		Test  *testConfig
+		Items []item
+		Ptr   *item
+	}
+
+	orig := &config{Test: newTestConfig()}
+	if err := Read(strings.NewReader(testConfigTOML), orig.Test); err != nil {
+		t.Fatal(err)
+	}
+	orig.Items = []item{{"first", []string{"a"}}}
+	orig.Ptr = &item{"pointed", nil}
+
+	c := Clone(orig).(*config)
+
+	if !reflect.DeepEqual(c, orig) {
+		t.Fatal("clone differs")
+	}
+	if changes := Diff(orig, c); len(changes) != 0 {
+		t.Fatal(changes)
+	}
+
+	MustAssign(c, "test.foo.key11=changed")
+	MustAssign(c, "test.baz.embed2.embedded=true")
+	MustAssign(c, "test.ext.a.average=1")
+	MustAssign(c, "items.0.name=changed")
+	MustAssign(c, "items.0.tags=changed")
+	MustAssign(c, "ptr.name=changed")
+	c.Test.Ext["new"] = new(testExtA)
+
+	testConfigValues(t, orig.Test)
+
+	if orig.Items[0].Name != "first" || orig.Items[0].Tags[0] != "a" {
+		t.Error(orig.Items)
+	}
+	if orig.Ptr.Name != "pointed" {
+		t.Error(orig.Ptr)
+	}
+	if len(orig.Test.Ext) != 2 {
+		t.Error(orig.Test.Ext)
+	}
+
+	if n := len(Diff(orig, c)); n != 7 {
+		t.Error(Diff(orig, c))
+	}
+}
+
+type testDisplayLevel int
+
+func (l *testDisplayLevel) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	*l = testDisplayLevel(n)
+	return err
+}
+
+func (l *testDisplayLevel) String() string {
+	return "lvl" // For display only.
+}
+
+func TestCloneCustom(t *testing.T) {
+	type config struct {
+		Level testDisplayLevel
+		Time  time.Time
+		Limit *big.Int
+	}
+
+	helsinki, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	orig := &config{
+		Level: 3,
+		Time:  time.Date(2018, 1, 2, 3, 4, 5, 0, helsinki),
+		Limit: big.NewInt(100),
+	}
+
+	c := Clone(orig).(*config)
+
+	if !reflect.DeepEqual(c, orig) {
+		t.Errorf("clone differs: %#v", c)
+	}
+	if c.Limit == orig.Limit {
+		t.Error("pointer is shared")
+	}
+
+	MustAssign(c, "limit=200")
+	if orig.Limit.Int64() != 100 {
+		t.Error(orig.Limit)
+	}
+}
@@ -32,8 +32,10 @@ type testConfig struct {
 	Bar int
 
 	Baz struct {
-		Quux     testConfigQuux
-		Interval time.Duration
+		Quux       testConfigQuux
+		Interval   time.Duration
+		SampleRate int    `confi:"sample_rate" help:"Audio sample rate"`
+		Secret     string `confi:"-"`
 
 		TestConfigEmbed
 		Embed1 struct {
@@ -76,6 +78,8 @@ var testConfigTOML = `bar = 12345
 [baz]
 embedded = false
 interval = "10h9m8.007006005s"
+# Audio sample rate
+sample_rate = 48000
 
 [baz.embed1]
 embedded = false
@@ -177,6 +181,9 @@ func testConfigValues(t *testing.T, c *testConfig) {
 	if !c.Baz.Quux.Key_b {
 		t.Error(c.Baz.Quux.Key_b)
 	}
+	if c.Baz.SampleRate != 48000 {
+		t.Error(c.Baz.SampleRate)
+	}
 	if c.Baz.EmBedded {
 		t.Error(c.Baz.EmBedded)
 	}
@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Parser converts a string representation to a value of a registered type.
+type Parser func(repr string) (interface{}, error)
+
+// Formatter converts a value of a registered type to a string representation.
+type Formatter func(value interface{}) string
+
+type customType struct {
+	parse  Parser
+	format Formatter
+}
+
+var (
+	typeLock sync.RWMutex
+	types    = make(map[reflect.Type]customType)
+)
+
+// RegisterType associates a field type with parse and format functions.  It
+// is intended for third-party types (such as *url.URL or os.FileMode) which
+// can't be given methods.  The parser must return a value of the registered
+// type.  If format is nil, values are formatted using fmt.Sprint.  Registered
+// types take precedence over the encoding.TextUnmarshaler and flag.Value
+// methods, and over the built-in handling of basic types.
+func RegisterType(t reflect.Type, parse Parser, format Formatter) {
+	if format == nil {
+		format = func(x interface{}) string { return fmt.Sprint(x) }
+	}
+
+	typeLock.Lock()
+	defer typeLock.Unlock()
+
+	types[t] = customType{parse, format}
+}
+
+// unregisterType removes a registered type.  It is used by tests.
+func unregisterType(t reflect.Type) {
+	typeLock.Lock()
+	defer typeLock.Unlock()
+
+	delete(types, t)
+}
+
+func registeredType(t reflect.Type) (ct customType, found bool) {
+	typeLock.RLock()
+	defer typeLock.RUnlock()
+
+	ct, found = types[t]
+	return
+}
+
+// Custom types are parsed and formatted by registered functions or by their
+// own methods instead of by kind.  A type is custom if it has been registered,
+// or if it (or a pointer to it) implements encoding.TextUnmarshaler or
+// flag.Value.  The value is formatted using encoding.TextMarshaler if it is
+// implemented, or flag.Value.
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
+// isCustomType may also be a pointer type.
+func isCustomType(t reflect.Type) bool {
+	if _, found := registeredType(t); found {
+		return true
+	}
+	return customPtrType(t) != nil
+}
+
+// customPtrType returns the pointer type which implements the parsing method,
+// or nil.
+func customPtrType(t reflect.Type) reflect.Type {
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PtrTo(t)
+	}
+	if t.Implements(textUnmarshalerType) || t.Implements(flagValueType) {
+		return t
+	}
+	return nil
+}
+
+// isScalarType is a custom type or a basic type which can be parsed from a
+// string.
+func isScalarType(t reflect.Type) bool {
+	return isCustomType(t) || isScalarKind(t.Kind())
+}
+
+// setCustomFromString parses repr if the node has a custom type.  The node
+// must be addressable.
+func setCustomFromString(node reflect.Value, repr string) bool {
+	t := node.Type()
+
+	if ct, found := registeredType(t); found {
+		x, err := ct.parse(repr)
+		if err != nil {
+			panic(err)
+		}
+
+		v := reflect.ValueOf(x)
+		if !v.IsValid() {
+			v = reflect.Zero(t)
+		}
+		if v.Type() != t {
+			panic(fmt.Errorf("parser of %s returned %s", t, v.Type()))
+		}
+
+		node.Set(v)
+		return true
+	}
+
+	p := customPtrType(t)
+	if p == nil {
+		return false
+	}
+
+	x := reflect.New(p.Elem())
+
+	var err error
+	switch v := x.Interface().(type) {
+	case encoding.TextUnmarshaler:
+		err = v.UnmarshalText([]byte(repr))
+
+	case flag.Value:
+		err = v.Set(repr)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	if t.Kind() == reflect.Ptr {
+		node.Set(x)
+	} else {
+		node.Set(x.Elem())
+	}
+	return true
+}
+
+// formatCustom value of a custom type.  Nil pointer is represented by the
+// empty string.  Panics if the value can't be marshaled.
+func formatCustom(value reflect.Value) string {
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return ""
+	}
+
+	if ct, found := registeredType(value.Type()); found {
+		return ct.format(value.Interface())
+	}
+
+	m := value
+	if value.Kind() != reflect.Ptr && !value.Type().Implements(textMarshalerType) && !value.Type().Implements(flagValueType) {
+		m = reflect.New(value.Type())
+		m.Elem().Set(value)
+	}
+
+	switch x := m.Interface().(type) {
+	case encoding.TextMarshaler:
+		text, err := x.MarshalText()
+		if err != nil {
+			panic(err)
+		}
+		return string(text)
+
+	case flag.Value:
+		return x.String()
+	}
+
+	return fmt.Sprint(value.Interface())
+}
+
+// displayCustom is like formatCustom, but it falls back to the default format
+// if the value can't be marshaled.
+func displayCustom(value reflect.Value) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = fmt.Sprint(value.Interface())
+		}
+	}()
+
+	return formatCustom(value)
+}
+
+// copyCustom value without formatting and parsing it, which could lose
+// information.  A pointer value is copied to a new pointer.  Values set by
+// this package are always allocated anew, so the copy doesn't share them with
+// the original.
+func copyCustom(value reflect.Value) reflect.Value {
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return value
+	}
+
+	c := reflect.New(value.Type().Elem())
+	c.Elem().Set(value.Elem())
+	return c
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testLevel int
+
+func (l testLevel) MarshalText() ([]byte, error) {
+	return []byte([]string{"debug", "info", "error"}[l]), nil
+}
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	for i, s := range []string{"debug", "info", "error"} {
+		if string(text) == s {
+			*l = testLevel(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid level: %q", text)
+}
+
+type testTextConfig struct {
+	Level   testLevel
+	Levels  []testLevel
+	Address net.IP
+	Peers   []net.IP
+	Start   time.Time `required:"true"`
+	Limit   *big.Int
+}
+
+func TestTextFields(t *testing.T) {
+	c := &testTextConfig{Level: 1}
+
+	err := Read(strings.NewReader(`level = "error"
+levels = ["debug", "info"]
+address = "10.0.0.1"
+peers = ["10.0.0.2", "::1"]
+start = "2018-01-02T03:04:05Z"
+limit = 123456789012345678901234567890
+`), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Level != 2 || !reflect.DeepEqual(c.Levels, []testLevel{0, 1}) {
+		t.Error(c.Level, c.Levels)
+	}
+	if !c.Address.Equal(net.IPv4(10, 0, 0, 1)) || len(c.Peers) != 2 || !c.Peers[1].Equal(net.IPv6loopback) {
+		t.Error(c.Address, c.Peers)
+	}
+	if !c.Start.Equal(time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Error(c.Start)
+	}
+	if c.Limit.String() != "123456789012345678901234567890" {
+		t.Error(c.Limit)
+	}
+
+	if err := Assign(c, "level=fatal"); err == nil || !strings.Contains(err.Error(), "invalid level") {
+		t.Error(err)
+	}
+
+	var defaults []string
+	for _, s := range Settings(c) {
+		defaults = append(defaults, s.Path+" "+s.Default)
+	}
+	if !reflect.DeepEqual(defaults, []string{
+		"level error",
+		"levels [debug info]",
+		"address 10.0.0.1",
+		"peers [10.0.0.2 ::1]",
+		"start 2018-01-02T03:04:05Z",
+		"limit 123456789012345678901234567890",
+	}) {
+		t.Error(defaults)
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := Write(b, c); err != nil {
+		t.Fatal(err)
+	}
+	c2 := new(testTextConfig)
+	if err := Read(bytes.NewReader(b.Bytes()), c2); err != nil {
+		t.Fatal(err, b)
+	}
+	if changes := Diff(c, c2); len(changes) != 0 {
+		t.Error(changes)
+	}
+
+	c3 := Clone(c).(*testTextConfig)
+	MustAssign(c3, "limit=1")
+	MustAssign(c3, "levels=[\"error\"]")
+	if c.Limit.String() != "123456789012345678901234567890" {
+		t.Error("clone shares value:", c.Limit)
+	}
+
+	changes := Diff(c, c3)
+	if len(changes) != 2 || changes[0].String() != `levels: ["debug" "info"] -> ["error"]` || changes[1].String() != `limit: "123456789012345678901234567890" -> "1"` {
+		t.Error(changes)
+	}
+
+	if err := Validate(new(testTextConfig)); err == nil || !strings.Contains(err.Error(), "start: value is required") {
+		t.Error(err)
+	}
+}
+
+func TestTextFieldErrors(t *testing.T) {
+	c := new(testTextConfig)
+
+	for _, s := range Settings(c) {
+		if s.Default != "" {
+			t.Errorf("%s default: %q", s.Path, s.Default)
+		}
+	}
+
+	c.Start = time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := Write(ioutil.Discard, c); err == nil || !strings.Contains(err.Error(), "year outside of range") {
+		t.Error(err)
+	}
+	if err := Interpolate(c); err != nil {
+		t.Error("Interpolate:", err)
+	}
+
+	refs := map[string]interface{}{
+		"text": c,
+		"ref":  &testInterpolateConfig{Address: "${text.start}"},
+	}
+	if err := Interpolate(refs); err == nil || !strings.Contains(err.Error(), "ref.address") {
+		t.Error("Interpolate reference:", err)
+	}
+
+	c.Start = time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := Interpolate(refs); err != nil {
+		t.Error("Interpolate reference:", err)
+	}
+	if s := refs["ref"].(*testInterpolateConfig).Address; s != "2018-01-02T03:04:05Z" {
+		t.Error(s)
+	}
+	if changes := Diff(new(testTextConfig), c); len(changes) != 1 {
+		t.Error(changes)
+	}
+}
+
+type testRate struct {
+	N   int
+	Per time.Duration
+}
+
+func (r *testRate) Set(s string) (err error) {
+	tokens := strings.SplitN(s, "/", 2)
+	if len(tokens) != 2 {
+		return fmt.Errorf("invalid rate: %q", s)
+	}
+	if _, err = fmt.Sscan(tokens[0], &r.N); err != nil {
+		return
+	}
+	r.Per, err = time.ParseDuration(tokens[1])
+	return
+}
+
+func (r *testRate) String() string {
+	return fmt.Sprintf("%d/%s", r.N, r.Per)
+}
+
+type testFlagValueConfig struct {
+	Rate  testRate
+	Burst *testRate
+	Rates []testRate
+}
+
+func TestFlagValueFields(t *testing.T) {
+	c := &testFlagValueConfig{Rate: testRate{10, time.Second}}
+
+	var defaults []string
+	for _, s := range Settings(c) {
+		defaults = append(defaults, s.Path+" "+s.Default)
+	}
+	if !reflect.DeepEqual(defaults, []string{"rate 10/1s", "burst ", "rates "}) {
+		t.Error(defaults)
+	}
+
+	err := Read(strings.NewReader(`rate = "5/1m0s"
+burst = "100/1s"
+rates = ["1/1s", "2/1s"]
+`), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Rate != (testRate{5, time.Minute}) || c.Burst == nil || *c.Burst != (testRate{100, time.Second}) {
+		t.Error(c.Rate, c.Burst)
+	}
+	if !reflect.DeepEqual(c.Rates, []testRate{{1, time.Second}, {2, time.Second}}) {
+		t.Error(c.Rates)
+	}
+
+	if err := Assign(c, "rate=fast"); err == nil || !strings.Contains(err.Error(), "invalid rate") {
+		t.Error(err)
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := Write(b, c); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); !strings.Contains(s, `rate = "5/1m0s"`) || !strings.Contains(s, `burst = "100/1s"`) {
+		t.Error(s)
+	}
+}
+
+type testRegisteredConfig struct {
+	Pattern  *regexp.Regexp
+	Location *time.Location
+	Filters  []*regexp.Regexp
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	regexpType := reflect.TypeOf((*regexp.Regexp)(nil))
+	RegisterType(regexpType, func(repr string) (interface{}, error) {
+		return regexp.Compile(repr)
+	}, nil)
+	defer unregisterType(regexpType)
+
+	locationType := reflect.TypeOf((*time.Location)(nil))
+	RegisterType(locationType, func(repr string) (interface{}, error) {
+		return time.LoadLocation(repr)
+	}, func(x interface{}) string {
+		return x.(*time.Location).String()
+	})
+	defer unregisterType(locationType)
+
+	c := &testRegisteredConfig{Location: time.UTC}
+
+	var defaults []string
+	for _, s := range Settings(c) {
+		defaults = append(defaults, s.Path+" "+s.Type.String()+" "+s.Default)
+	}
+	if !reflect.DeepEqual(defaults, []string{
+		"pattern *regexp.Regexp ",
+		"location *time.Location UTC",
+		"filters []*regexp.Regexp ",
+	}) {
+		t.Error(defaults)
+	}
+
+	err := Read(strings.NewReader(`pattern = "^a+$"
+location = "UTC"
+filters = ["b", "c+"]
+`), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.Pattern.MatchString("aaa") || c.Location != time.UTC || len(c.Filters) != 2 || c.Filters[1].String() != "c+" {
+		t.Error(c)
+	}
+
+	if err := Assign(c, "pattern=("); err == nil || !strings.Contains(err.Error(), "pattern: error parsing regexp") {
+		t.Error(err)
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := Write(b, c); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); !strings.Contains(s, `pattern = "^a+$"`) || !strings.Contains(s, `location = "UTC"`) || !strings.Contains(s, `filters = ["b", "c+"]`) {
+		t.Error(s)
+	}
+}
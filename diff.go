@@ -0,0 +1,156 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Change of a configuration value.  String values are quoted, and secrets are
+// redacted.  A value which doesn't exist in one of the configurations (such as
+// a map entry or a struct slice element) is represented by an empty string.
+type Change struct {
+	Path string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+// Diff compares two configuration objects of the same type.  The paths of the
+// changed values are listed in schema order.
+func Diff(oldConfig, newConfig interface{}) (changes []Change) {
+	oldPaths, oldValues := collectValues(oldConfig)
+	newPaths, newValues := collectValues(newConfig)
+
+	for _, path := range oldPaths {
+		if x, y := oldValues[path], newValues[path]; x != y {
+			changes = append(changes, Change{path, x.display(), y.display()})
+		}
+	}
+
+	for _, path := range newPaths {
+		if _, found := oldValues[path]; !found {
+			changes = append(changes, Change{path, "", newValues[path].display()})
+		}
+	}
+
+	return
+}
+
+type collectedValue struct {
+	repr   string
+	secret bool
+}
+
+func (v collectedValue) display() string {
+	if v.secret {
+		return redacted
+	}
+	return v.repr
+}
+
+type valueCollector struct {
+	paths  []string
+	values map[string]collectedValue
+}
+
+func collectValues(config interface{}) ([]string, map[string]collectedValue) {
+	vc := valueCollector{values: make(map[string]collectedValue)}
+	vc.collect("", reflect.ValueOf(config))
+	return vc.paths, vc.values
+}
+
+func (vc *valueCollector) collect(path string, node reflect.Value) {
+	if node.Kind() == reflect.Interface {
+		node = node.Elem()
+	}
+	if node.Kind() == reflect.Ptr {
+		if node.IsNil() {
+			return
+		}
+		node = node.Elem()
+	}
+
+	if isCustomType(node.Type()) {
+		vc.add(path, strconv.Quote(displayCustom(node)))
+		return
+	}
+
+	switch node.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		vc.add(path, fmt.Sprint(node.Interface()))
+
+	case reflect.String:
+		vc.addValue(path, collectedValue{strconv.Quote(node.String()), isSecret(node)})
+
+	case reflect.Slice:
+		if node.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < node.Len(); i++ {
+				vc.collect(joinPath(path, strconv.Itoa(i)), node.Index(i))
+			}
+		} else if node.Type().Elem().Kind() == reflect.String || isCustomType(node.Type().Elem()) {
+			vc.add(path, formatQuoted(node))
+		} else {
+			vc.add(path, fmt.Sprint(node.Interface()))
+		}
+
+	case reflect.Map:
+		for _, key := range reflectMapKeyStrings(node) {
+			k := key
+			if strings.Contains(k, ".") {
+				k = fmt.Sprintf("%q", k)
+			}
+			vc.collect(joinPath(path, k), node.MapIndex(reflect.ValueOf(key)))
+		}
+
+	case reflect.Struct:
+		for i := 0; i < node.Type().NumField(); i++ {
+			value := node.Field(i)
+			if !value.CanInterface() {
+				continue
+			}
+
+			key, ok := fieldKey(node.Type().Field(i))
+			if !ok {
+				continue
+			}
+
+			p := path
+			if key != "" {
+				p = joinPath(path, key)
+			}
+
+			vc.collect(p, value)
+		}
+	}
+}
+
+// formatQuoted slice of strings or custom values.
+func formatQuoted(slice reflect.Value) string {
+	items := make([]string, slice.Len())
+	for i := range items {
+		if item := slice.Index(i); isCustomType(item.Type()) {
+			items[i] = displayCustom(item)
+		} else {
+			items[i] = redact(item, item.String())
+		}
+	}
+	return fmt.Sprintf("%q", items)
+}
+
+func (vc *valueCollector) add(path, repr string) {
+	vc.addValue(path, collectedValue{repr: repr})
+}
+
+func (vc *valueCollector) addValue(path string, v collectedValue) {
+	vc.paths = append(vc.paths, path)
+	vc.values[path] = v
+}
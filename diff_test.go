@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	type config struct {
+		Foo     int
+		Bar     string
+		List    []string
+		Items   []item
+		Timeout time.Duration
+		Ptr     *item
+		Ext     map[string]interface{}
+	}
+
+	a := &config{
+		Foo:   1,
+		Bar:   "same",
+		List:  []string{"x"},
+		Items: []item{{"first"}, {"second"}},
+		Ptr:   &item{"pointed"},
+		Ext: map[string]interface{}{
+			"a":   &testExtA{1},
+			"b.c": &testExtB{2},
+		},
+	}
+
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Error(changes)
+	}
+
+	b := &config{
+		Foo:     2,
+		Bar:     "same",
+		List:    []string{"x", "y"},
+		Items:   []item{{"first"}},
+		Timeout: time.Second,
+		Ext: map[string]interface{}{
+			"a": &testExtA{3},
+			"d": &testExtA{4},
+		},
+	}
+
+	if changes := Diff(a, b); !reflect.DeepEqual(changes, []Change{
+		{"foo", "1", "2"},
+		{"list", `["x"]`, `["x" "y"]`},
+		{"items.1.name", `"second"`, ""},
+		{"timeout", "0s", "1s"},
+		{"ptr.name", `"pointed"`, ""},
+		{"ext.a.average", "1", "3"},
+		{`ext."b.c".beverage`, "2", ""},
+		{"ext.d.average", "", "4"},
+	}) {
+		t.Errorf("%q", changes)
+	}
+}
@@ -5,8 +5,8 @@
 /*
 
 Package confi is an ergonomic configuration parsing toolkit.  The schema is
-declared using a struct type, and values can be read from TOML files or set via
-command-line flags.
+declared using a struct type, and values can be read from TOML, JSON or YAML
+files or environment variables, or set via command-line flags.
 
 A pointer to a preallocated configuration object of a user-defined struct type
 must be passed to all functions.  The type can have an arbitrary number of
@@ -14,18 +14,58 @@ nested structs (either embedded or through an initialized pointer).  Only
 exported fields can be used.  The object can be initialized with default
 values.
 
-Slices of structs can be populated by appending TOML table arrays, or by
-indexing on the command line.
+FileReader, FlagReader and Buffer choose the file format based on the filename
+extension.  Additional formats can be registered.
+
+A TOML file can include other TOML files with a top-level directive, such as
+include = ["common.toml", "conf.d/*.toml"].  The filenames and glob patterns
+are relative to the including file, and the included files are read before
+the rest of the including file.
+
+String values can refer to other settings and environment variables, such as
+"${paths.root}/log" or "${env:HOME}".  Buffer resolves the references after
+all sources have been applied; see Interpolate.
+
+Slices of structs can be populated by appending TOML table arrays, JSON arrays
+of objects or YAML sequences of mappings, or by indexing on the command line.
 
 Dynamically created subtrees are supported via map[string]interface{} nodes.
 The map values must be struct pointers.
 
-The field names are spelled in lower case in TOML files and on the
-command-line.  The accessor functions and flag values use dotted paths to
-identify the field, such as "audio.samplerate".
+By default, the field names are spelled in lower case in configuration files
+and on the command-line.  The accessor functions and flag values use dotted
+paths to identify the field, such as "audio.samplerate".
+
+The naming strategy can be changed globally via the KeyNaming variable, e.g.
+to SnakeCase.  The key of a field can be overridden with a struct tag, such as
+`confi:"sample_rate"`.  A field tagged with `confi:"-"` is hidden.
 
 Supported field types are bool, int, int8, int16, int32, int64, uint, uint8,
-uint16, uint32, uint64, float32, float64, string, []string, and time.Duration.
+uint16, uint32, uint64, float32, float64, string, time.Duration, types which
+implement encoding.TextUnmarshaler (such as net.IP, netip.Prefix and
+time.Time) or flag.Value, and slices of them.  Values of such types are written
+using encoding.TextMarshaler or flag.Value.  Parse and format functions can be
+registered for other types; see RegisterType.
+
+Built-in handling is provided for *url.URL, net.IPNet (CIDR notation) and
+os.FileMode (octal).  The Address and ByteSize types represent "host:port"
+addresses and byte sizes with units, such as "64MiB".
+
+Passwords and other credentials can be stored in fields of type Secret.  Their
+values can be read from files, and they are never displayed or written.
+
+Alternatively, RegisterFlags defines a command-line flag for each setting,
+such as -audio.samplerate.
+
+Settings can be documented using "help" tags, e.g. `help:"Sample rate in Hz"`.
+The descriptions are shown by PrintSettings and FlagUsage, and written as
+comments by Write.
+
+Field values can be constrained using struct tags, such as `min:"1"`, and
+structs can implement the Validator interface.  See Validate for details.
+
+Configuration objects obtained from other sources can be layered using Merge,
+which overlays the non-default values of one object onto another.
 
 The Get method is provided for completeness; the intended way to access
 configuration values is through direct struct field access.
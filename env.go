@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+var envNameReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// ReadEnv is equivalent to ReadEnviron(os.Environ(), prefix, config, false).
+func ReadEnv(prefix string, config interface{}) error {
+	return ReadEnviron(os.Environ(), prefix, config, false)
+}
+
+// ReadEnviron sets fields of the configuration object from environment
+// variables.  The environment is a list of "NAME=value" strings, such as
+// returned by os.Environ.  Variables whose names don't start with the prefix
+// are skipped.  Unknown keys are silently skipped if ignoreUnknown is true.
+//
+// Variable names are derived from the configuration paths by converting them
+// to upper case and replacing dots and dashes with underscores.  E.g. with
+// prefix "APP_", the path "audio.samplerate" is set by APP_AUDIO_SAMPLERATE.
+// Elements of struct slices cannot be set.
+//
+// See SetFromString for parsing rules.
+func ReadEnviron(environ []string, prefix string, config interface{}, ignoreUnknown bool) error {
+	paths := make(map[string]string)
+
+	for _, s := range Settings(config) {
+		if strings.ContainsAny(s.Path, `#"`) {
+			continue
+		}
+
+		name := prefix + envNameReplacer.Replace(strings.ToUpper(s.Path))
+		if _, dup := paths[name]; !dup {
+			paths[name] = s.Path
+		}
+	}
+
+	for _, entry := range environ {
+		tokens := strings.SplitN(entry, "=", 2)
+		if len(tokens) != 2 || !strings.HasPrefix(tokens[0], prefix) {
+			continue
+		}
+
+		name, repr := tokens[0], tokens[1]
+
+		path, found := paths[name]
+		if !found {
+			if ignoreUnknown {
+				continue
+			}
+			return unknownKeyError(fmt.Sprintf("unknown config environment variable: %q", name))
+		}
+
+		restore := withSource(config, Source{Kind: SourceEnv, Name: name})
+		err := SetFromString(config, path, repr)
+		restore()
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadEnviron(t *testing.T) {
+	c := newTestConfig()
+
+	if err := ReadEnviron([]string{
+		"PATH=/usr/bin:/bin",
+		"TEST_FOO_KEY1=true",
+		"TEST_FOO_KEY2=-10",
+		"TEST_FOO_KEY2B=-128",
+		"TEST_FOO_KEY3A=-32768",
+		"TEST_FOO_KEY3=-11",
+		"TEST_FOO_KEY4=-100000000000000",
+		"TEST_FOO_KEY5=10",
+		"TEST_FOO_KEY5B=255",
+		"TEST_FOO_KEY6A=65535",
+		"TEST_FOO_KEY6=11",
+		"TEST_FOO_KEY7=100000000000000",
+		"TEST_FOO_KEY8=1.5",
+		"TEST_FOO_KEY9=1.0000000000005",
+		"TEST_FOO_KEY10=hello, world",
+		`TEST_FOO_KEY11=["hello", "world"]`,
+		"TEST_BAR=12345",
+		"TEST_BAZ_QUUX_KEY_A=true",
+		"TEST_BAZ_QUUX_KEY_B=yes",
+		"TEST_BAZ_INTERVAL=10h9m8s7ms6µs5ns",
+		"TEST_BAZ_SAMPLE_RATE=48000",
+		"TEST_EXT_A_AVERAGE=123",
+		"TEST_EXT_B_BEVERAGE=456",
+	}, "TEST_", c, false); err != nil {
+		t.Fatal(err)
+	}
+
+	testConfigValues(t, c)
+
+	if err := ReadEnviron([]string{"TEST_NONEXISTENT=1"}, "TEST_", c, false); err == nil {
+		t.Error("unknown variable")
+	}
+	if err := ReadEnviron([]string{"TEST_NONEXISTENT=1"}, "TEST_", c, true); err != nil {
+		t.Error(err)
+	}
+	if err := ReadEnviron([]string{"TEST_BAR=x"}, "TEST_", c, true); err == nil {
+		t.Error("invalid value")
+	}
+}
+
+func TestBufferEnvReader(t *testing.T) {
+	os.Setenv("CONFI_TEST_BAR", "12345")
+	defer os.Unsetenv("CONFI_TEST_BAR")
+
+	b := NewBuffer()
+	b.Assigner().Set("bar=1")
+	b.EnvReader().Set("CONFI_TEST_")
+	b.Assigner().Set("foo.key2=2")
+
+	c := newTestConfig()
+	if err := b.Apply(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 12345 {
+		t.Error(c.Bar)
+	}
+	if c.Foo.Key2 != 2 {
+		t.Error(c.Foo.Key2)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error describes a problem with a configuration source.  The location
+// fields are set when they are known.  TOML syntax errors have no Column, as
+// the parser reports only the line.
+type Error struct {
+	Filename string
+	Line     int
+	Column   int
+	Path     string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	var pos string
+
+	switch {
+	case e.Filename != "":
+		pos = e.Filename + ":"
+		if e.Line > 0 {
+			pos += fmt.Sprintf("%d:", e.Line)
+			if e.Column > 0 {
+				pos += fmt.Sprintf("%d:", e.Column)
+			}
+		}
+		pos += " "
+
+	case e.Line > 0:
+		pos = fmt.Sprintf("line %d", e.Line)
+		if e.Column > 0 {
+			pos += fmt.Sprintf(", column %d", e.Column)
+		}
+		pos += ": "
+	}
+
+	if e.Path != "" {
+		pos += e.Path + ": "
+	}
+
+	return pos + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrap err in an Error.  If err is already an Error, its unknown location
+// fields are filled in from e.
+func (e Error) wrap(err error) *Error {
+	x, ok := err.(*Error)
+	if !ok {
+		e.Err = err
+		return &e
+	}
+
+	copy := *x
+	if copy.Filename == "" {
+		copy.Filename = e.Filename
+	}
+	if copy.Line == 0 {
+		copy.Line = e.Line
+		copy.Column = e.Column
+	}
+	if copy.Path == "" {
+		copy.Path = e.Path
+	}
+	return &copy
+}
+
+// ErrorList is returned by functions which don't stop at the first error.
+type ErrorList []*Error
+
+func (list ErrorList) Error() string {
+	lines := make([]string, len(list))
+	for i, e := range list {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// add err to the list.  Location fields are filled in from pos.
+func (list *ErrorList) add(pos Error, err error) {
+	if x, ok := err.(ErrorList); ok {
+		for _, e := range x {
+			*list = append(*list, pos.wrap(e))
+		}
+	} else {
+		*list = append(*list, pos.wrap(err))
+	}
+}
+
+func (list ErrorList) err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+const testBadTOML = `bar = "x"
+
+[foo]
+key1 = true
+key2 = "y"
+nonexistent = 1
+
+[[nonexistent]]
+a = 1
+
+[baz]
+interval = "forever"
+`
+
+func TestReadAll(t *testing.T) {
+	c := newTestConfig()
+
+	err := ReadAll(strings.NewReader(testBadTOML), c)
+	if err == nil {
+		t.Fatal("no error")
+	}
+	t.Log(err)
+
+	errs, ok := err.(ErrorList)
+	if !ok {
+		t.Fatalf("%T", err)
+	}
+
+	for i, spec := range []struct {
+		line   int
+		column int
+		path   string
+	}{
+		{1, 7, "bar"},
+		{5, 8, "foo.key2"},
+		{6, 15, "foo.nonexistent"},
+		{8, 1, "nonexistent"},
+		{12, 12, "baz.interval"},
+	} {
+		if i >= len(errs) {
+			t.Errorf("missing error at line %d", spec.line)
+			continue
+		}
+		if e := errs[i]; e.Line != spec.line || e.Column != spec.column || e.Path != spec.path {
+			t.Errorf("%d: %#v", i, e)
+		}
+	}
+	if len(errs) != 5 {
+		t.Error(len(errs))
+	}
+
+	if !c.Foo.Key1 {
+		t.Error("foo.key1 was not set")
+	}
+
+	if err := ReadAll(strings.NewReader("bar = \n"), c); err == nil {
+		t.Error("parse error")
+	} else if errs := err.(ErrorList); len(errs) != 1 || errs[0].Line == 0 {
+		t.Errorf("%#v", errs)
+	}
+}
+
+func TestReadFileError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "bad.toml")
+	if err := ioutil.WriteFile(filename, []byte("[foo]\nkey1 = true\nkey2 = \"y\"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []func() error{
+		func() error { return ReadFile(filename, newTestConfig()) },
+		func() error { return FileReader(newTestConfig()).Set(filename) },
+		func() error {
+			b := NewBuffer()
+			b.FileReader().Set(filename)
+			return b.Apply(newTestConfig())
+		},
+	} {
+		err := f()
+		e, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%T: %v", err, err)
+			continue
+		}
+		if e.Filename != filename || e.Line != 3 || e.Column != 8 || e.Path != "foo.key2" {
+			t.Errorf("%#v", e)
+		}
+		if s := e.Error(); s != filename+`:3:8: foo.key2: strconv.ParseInt: parsing "y": invalid syntax` {
+			t.Error(s)
+		}
+	}
+
+	if err := ReadFile(filename+"x", newTestConfig()); !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
+
+func TestFlushAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "bad.toml")
+	if err := ioutil.WriteFile(filename, []byte(testBadTOML), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuffer()
+	b.FileReader().Set(filename)
+	b.Assigner().Set("foo.key3=z")
+	b.FileReader().Set(path.Join(dir, "nonexistent.toml"))
+
+	if err := b.Flush(newTestConfig(), false); err == nil {
+		t.Error("Flush")
+	} else if _, ok := err.(ErrorList); ok {
+		t.Error(err)
+	}
+
+	err = b.FlushAll(newTestConfig(), false)
+	if err == nil {
+		t.Fatal("FlushAll")
+	}
+	t.Log(err)
+
+	errs := err.(ErrorList)
+	if len(errs) != 7 {
+		t.Fatal(len(errs))
+	}
+	for _, e := range errs[:5] {
+		if e.Filename != filename {
+			t.Error(e)
+		}
+	}
+	if e := errs[5]; e.Filename != "" || !strings.HasPrefix(e.Error(), "foo.key3: ") {
+		t.Error(e)
+	}
+	if e := errs[6]; !os.IsNotExist(e.Err) {
+		t.Error(e)
+	}
+}
@@ -7,9 +7,12 @@ package confi
 import (
 	"errors"
 	"flag"
+	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"sort"
+	"strings"
 )
 
 // FileReader is equivalent to FlagReader(config, false).
@@ -18,8 +21,9 @@ func FileReader(config interface{}) flag.Value {
 }
 
 // FlagReader makes a ``dynamic value'' which reads files into the
-// configuration as it receives filenames.  Unknown keys are silently skipped
-// if ignoreUnknown is true.
+// configuration as it receives filenames.  The file format is chosen based on
+// the filename extension; see RegisterFormat.  Unknown keys are silently
+// skipped if ignoreUnknown is true.
 func FlagReader(config interface{}, ignoreUnknown bool) flag.Value {
 	return fileReader{config, ignoreUnknown}
 }
@@ -30,7 +34,7 @@ type fileReader struct {
 }
 
 func (fr fileReader) Set(filename string) error {
-	return readFile(filename, fr.config, fr.ignoreUnknown)
+	return readFormatFile(filename, fr.config, fr.ignoreUnknown, nil)
 }
 
 func (fileReader) String() string {
@@ -62,6 +66,67 @@ func (assigner) String() string {
 	return ""
 }
 
+// RegisterFlags defines a flag for each setting of the configuration, e.g.
+// "-audio.samplerate=48000".  Flag set defaults to the default flag set.
+// Boolean settings are boolean flags.  Setting descriptions are used as usage
+// texts.  Elements of struct slices are not included.
+//
+// Settings whose names are already defined in the flag set are skipped.  They
+// are reported as an ErrorList (with the paths of the settings) after the
+// other flags have been defined.
+//
+// See SetFromString for parsing rules.
+func RegisterFlags(flags *flag.FlagSet, config interface{}) error {
+	if flags == nil {
+		flags = flag.CommandLine
+	}
+
+	var errs ErrorList
+
+	for _, s := range Settings(config) {
+		if strings.Contains(s.Path, "#") {
+			continue
+		}
+
+		if flags.Lookup(s.Path) != nil {
+			errs.add(Error{Path: s.Path}, errors.New("flag already defined"))
+			continue
+		}
+
+		flags.Var(settingFlag{config, s.Path, s.Type.Kind() == reflect.Bool}, s.Path, s.Description)
+	}
+
+	return errs.err()
+}
+
+type settingFlag struct {
+	config interface{}
+	path   string
+	isBool bool
+}
+
+func (f settingFlag) Set(repr string) error {
+	defer withSource(f.config, Source{Kind: SourceFlag, Name: f.path})()
+	return SetFromString(f.config, f.path, repr)
+}
+
+func (f settingFlag) String() string {
+	if f.config == nil {
+		return ""
+	}
+
+	x, err := Get(f.config, f.path)
+	if err != nil {
+		return ""
+	}
+
+	return formatValue(x)
+}
+
+func (f settingFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
 // Buffer configuration files and assignments.
 type Buffer struct {
 	list []buffered
@@ -100,6 +165,13 @@ func (b *Buffer) DirReader(pattern string) flag.Value {
 	return bufferedDirReader{b, pattern}
 }
 
+// EnvReader makes a ``dynamic value'' which buffers environment variable
+// prefixes.  The environment is read when the buffer is flushed.  See
+// ReadEnviron for details.
+func (b *Buffer) EnvReader() flag.Value {
+	return bufferedEnvReader{b}
+}
+
 // Assigner makes a ``dynamic value'' which buffers assignment expressions to
 // be applied.
 func (b *Buffer) Assigner() flag.Value {
@@ -111,11 +183,47 @@ func (b Buffer) Apply(config interface{}) error {
 	return b.Flush(config, false)
 }
 
-// Flush files and assignments to the configuration.  Unknown keys are silently
-// skipped if ignoreUnknown is true.
+// Flush files and assignments to the configuration, interpolate references and
+// validate it.  The file format is chosen based on the filename extension; see
+// RegisterFormat.  Unknown keys are silently skipped if ignoreUnknown is true.
+// See Interpolate for the reference syntax.
 func (b Buffer) Flush(config interface{}, ignoreUnknown bool) error {
+	if err := b.flushEntries(config, ignoreUnknown); err != nil {
+		return err
+	}
+	if err := Interpolate(config); err != nil {
+		return err
+	}
+	return Validate(config)
+}
+
+// FlushAll is like Flush, but it doesn't stop at the first error.  The
+// returned error is an ErrorList.  TOML files are processed completely; other
+// formats may contribute only their first error.
+func (b Buffer) FlushAll(config interface{}, ignoreUnknown bool) error {
+	var errs ErrorList
+
+	for _, entry := range b.list {
+		if err := entry.flush(config, ignoreUnknown, &errs); err != nil {
+			errs.add(Error{}, err)
+		}
+	}
+
+	if err := Interpolate(config); err != nil {
+		errs.add(Error{}, err)
+	}
+
+	if err := Validate(config); err != nil {
+		errs.add(Error{}, err)
+	}
+
+	return errs.err()
+}
+
+// flushEntries without interpolation and validation.
+func (b Buffer) flushEntries(config interface{}, ignoreUnknown bool) error {
 	for _, entry := range b.list {
-		if err := entry.flush(config, ignoreUnknown); err != nil {
+		if err := entry.flush(config, ignoreUnknown, nil); err != nil {
 			return err
 		}
 	}
@@ -123,15 +231,18 @@ func (b Buffer) Flush(config interface{}, ignoreUnknown bool) error {
 }
 
 type buffered struct {
-	filename string
-	pattern  string
-	expr     string
+	filename  string
+	pattern   string
+	envPrefix string
+	expr      string
 }
 
-func (b buffered) flush(config interface{}, ignoreUnknown bool) error {
+// flush stops at the first error if errs is nil.  Otherwise file errors are
+// appended to it.
+func (b buffered) flush(config interface{}, ignoreUnknown bool, errs *ErrorList) error {
 	switch {
 	case b.filename != "":
-		return readFile(b.filename, config, ignoreUnknown)
+		return readFormatFile(b.filename, config, ignoreUnknown, errs)
 
 	case b.pattern != "":
 		names, err := filepath.Glob(b.pattern)
@@ -140,12 +251,18 @@ func (b buffered) flush(config interface{}, ignoreUnknown bool) error {
 		}
 		sort.Strings(names)
 		for _, name := range names {
-			if err := readFileIfExists(name, config, ignoreUnknown); err != nil {
-				return err
+			if err := readFormatFileIfExists(name, config, ignoreUnknown, errs); err != nil {
+				if errs == nil {
+					return err
+				}
+				errs.add(Error{}, err)
 			}
 		}
 		return nil
 
+	case b.envPrefix != "":
+		return ReadEnviron(os.Environ(), b.envPrefix, config, ignoreUnknown)
+
 	case b.expr != "":
 		return assign(config, b.expr, ignoreUnknown)
 
@@ -191,6 +308,22 @@ func (bufferedDirReader) String() string {
 	return ""
 }
 
+type bufferedEnvReader struct {
+	b *Buffer
+}
+
+func (er bufferedEnvReader) Set(prefix string) error {
+	if prefix == "" {
+		return errors.New("environment variable prefix is empty")
+	}
+	er.b.list = append(er.b.list, buffered{envPrefix: prefix})
+	return nil
+}
+
+func (bufferedEnvReader) String() string {
+	return ""
+}
+
 type bufferedAssigner struct {
 	b *Buffer
 }
@@ -8,6 +8,7 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"reflect"
 	"testing"
 )
 
@@ -38,6 +39,7 @@ func TestAssigner(t *testing.T) {
 			"-c", "baz.quux.key_a=true",
 			"-c", "baz.quux.key_b=yes",
 			"-c", "baz.interval=10h9m8s7ms6µs5ns",
+			"-c", "baz.sample_rate=48000",
 			"-c", "ext.a.average=123",
 			"-c", "ext.b.beverage=456",
 		}); err != nil {
@@ -70,6 +72,8 @@ func TestAssigner(t *testing.T) {
 		"nonexistent.key1",
 		"baz.quux.nonexistent",
 		"baz.quux.0",
+		"baz.samplerate",
+		"baz.secret",
 		"0",
 	} {
 		t.Run("UnknownPath", func(t *testing.T) {
@@ -110,3 +114,95 @@ func TestAssigner(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterFlags(t *testing.T) {
+	c := newTestConfig()
+	c.Foo.Key11 = []string{"default"}
+
+	s := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := RegisterFlags(s, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if f := s.Lookup("baz.sample_rate"); f == nil {
+		t.Error("baz.sample_rate")
+	} else if f.Usage != "Audio sample rate" {
+		t.Error(f.Usage)
+	}
+	if f := s.Lookup("bar"); f == nil {
+		t.Error("bar")
+	} else if f.DefValue != "67890" {
+		t.Error(f.DefValue)
+	}
+	if f := s.Lookup("foo.key11"); f == nil {
+		t.Error("foo.key11")
+	} else if f.DefValue != `["default"]` {
+		t.Error(f.DefValue)
+	}
+
+	if err := s.Parse([]string{
+		"-foo.key1",
+		"-foo.key2=-10",
+		"-foo.key11", `["hello", "world"]`,
+		"-bar", "12345",
+		"-baz.quux.key_b",
+		"-baz.sample_rate=48000",
+		"-ext.a.average=123",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.Foo.Key1 {
+		t.Error(c.Foo.Key1)
+	}
+	if c.Foo.Key2 != -10 {
+		t.Error(c.Foo.Key2)
+	}
+	if !reflect.DeepEqual(c.Foo.Key11, []string{"hello", "world"}) {
+		t.Error(c.Foo.Key11)
+	}
+	if c.Bar != 12345 {
+		t.Error(c.Bar)
+	}
+	if !c.Baz.Quux.Key_b {
+		t.Error(c.Baz.Quux.Key_b)
+	}
+	if c.Baz.SampleRate != 48000 {
+		t.Error(c.Baz.SampleRate)
+	}
+	if a := c.Ext["a"].(*testExtA); a.Average != 123 {
+		t.Error(a.Average)
+	}
+
+	s.SetOutput(ioutil.Discard)
+
+	if err := s.Parse([]string{"-bar=x"}); err == nil {
+		t.Error("-bar=x")
+	}
+}
+
+func TestRegisterFlagsDefined(t *testing.T) {
+	c := newTestConfig()
+
+	s := flag.NewFlagSet("test", flag.ContinueOnError)
+	bar := s.String("bar", "", "not a setting")
+
+	err := RegisterFlags(s, c)
+	if list, ok := err.(ErrorList); !ok || len(list) != 1 || list[0].Path != "bar" {
+		t.Error(err)
+	}
+
+	if f := s.Lookup("bar"); f.Usage != "not a setting" {
+		t.Error(f.Usage)
+	}
+	if s.Lookup("foo.key2") == nil {
+		t.Error("foo.key2")
+	}
+
+	if err := s.Parse([]string{"-bar=x"}); err != nil {
+		t.Fatal(err)
+	}
+	if *bar != "x" || c.Bar != 67890 {
+		t.Error(*bar, c.Bar)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Decoder reads a configuration file format into the configuration.  Unknown
+// keys must be silently skipped if ignoreUnknown is true.
+type Decoder func(r io.Reader, config interface{}, ignoreUnknown bool) error
+
+type format struct {
+	decode Decoder
+
+	// readFile is an optional alternative to decode.  If errs is non-nil,
+	// errors are appended to it instead of stopping at the first one.
+	readFile func(r io.Reader, filename string, config interface{}, ignoreUnknown bool, errs *ErrorList) error
+}
+
+var tomlFormat = format{read, readTOML}
+
+var (
+	formatLock sync.RWMutex
+	formats    = map[string]format{
+		".toml": tomlFormat,
+		".json": {decode: readJSON},
+		".yaml": {decode: readYAML},
+		".yml":  {decode: readYAML},
+		".env":  {decode: readEnvFile},
+	}
+)
+
+// RegisterFormat associates a filename extension (such as ".toml") with a
+// decoder.  It is used by FileReader, FlagReader and Buffer.  The built-in
+// formats are TOML (.toml), JSON (.json), YAML (.yaml and .yml) and
+// environment files (.env).  Files with unknown extensions are read as TOML.
+func RegisterFormat(ext string, decode Decoder) {
+	formatLock.Lock()
+	defer formatLock.Unlock()
+
+	formats[strings.ToLower(ext)] = format{decode: decode}
+}
+
+// unregisterFormat removes a file format.  It is used by tests.
+func unregisterFormat(ext string) {
+	formatLock.Lock()
+	defer formatLock.Unlock()
+
+	delete(formats, strings.ToLower(ext))
+}
+
+func fileFormat(filename string) format {
+	formatLock.RLock()
+	defer formatLock.RUnlock()
+
+	if f, found := formats[strings.ToLower(filepath.Ext(filename))]; found {
+		return f
+	}
+	return tomlFormat
+}
+
+// readFormatFile stops at the first error if errs is nil.  Otherwise errors
+// are appended to it, except for the error from opening the file, which is
+// returned.
+func readFormatFile(filename string, config interface{}, ignoreUnknown bool, errs *ErrorList) (err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	defer withSource(config, Source{Kind: SourceFile, Filename: filename})()
+
+	format := fileFormat(filename)
+
+	if format.readFile != nil {
+		return format.readFile(f, filename, config, ignoreUnknown, errs)
+	}
+
+	if err = format.decode(f, config, ignoreUnknown); err != nil {
+		pos := Error{Filename: filename}
+		if errs == nil {
+			return pos.wrap(err)
+		}
+		errs.add(pos, err)
+		err = nil
+	}
+	return
+}
+
+func readFormatFileIfExists(filename string, config interface{}, ignoreUnknown bool, errs *ErrorList) (err error) {
+	err = readFormatFile(filename, config, ignoreUnknown, errs)
+	if err != nil && os.IsNotExist(err) {
+		err = nil
+	}
+	return
+}
+
+// readEnvFile reads "NAME=value" lines.  Empty lines and comments are
+// skipped, and the value may be quoted.  See ReadEnviron for details; the
+// prefix is empty.
+func readEnvFile(r io.Reader, config interface{}, ignoreUnknown bool) error {
+	var environ []string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		tokens := strings.SplitN(line, "=", 2)
+		if len(tokens) != 2 {
+			return fmt.Errorf("invalid environment file line: %q", line)
+		}
+
+		name := strings.TrimSpace(tokens[0])
+		value := strings.TrimSpace(tokens[1])
+		if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') && value[n-1] == value[0] {
+			value = value[1 : n-1]
+		}
+
+		environ = append(environ, name+"="+value)
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	return ReadEnviron(environ, "", config, ignoreUnknown)
+}
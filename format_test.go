@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	RegisterFormat(".Test", func(r io.Reader, config interface{}, ignoreUnknown bool) error {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return assign(config, strings.TrimSpace(string(data)), ignoreUnknown)
+	})
+	defer unregisterFormat(".Test")
+
+	for name, data := range map[string]string{
+		"1.toml": "[foo]\nkey1 = true\n",
+		"2.json": `{"foo": {"key2": -10}}`,
+		"3.yaml": "foo:\n  key3: -11\n",
+		"4.env":  "# comment\n\nexport FOO_KEY4=-100000000000000\nFOO_KEY10=\"hello, world\"\n",
+		"5.test": "foo.key5=10",
+		"6.conf": "bar = 12345\n",
+		"7.yml":  "baz:\n  sample_rate: 48000\n",
+	} {
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := NewBuffer()
+	if err := b.DirReader("*.*").Set(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestConfig()
+	if err := b.Apply(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.Foo.Key1 {
+		t.Error(c.Foo.Key1)
+	}
+	if c.Foo.Key2 != -10 {
+		t.Error(c.Foo.Key2)
+	}
+	if c.Foo.Key3 != -11 {
+		t.Error(c.Foo.Key3)
+	}
+	if c.Foo.Key4 != -100000000000000 {
+		t.Error(c.Foo.Key4)
+	}
+	if c.Foo.Key5 != 10 {
+		t.Error(c.Foo.Key5)
+	}
+	if c.Foo.Key10 != "hello, world" {
+		t.Error(c.Foo.Key10)
+	}
+	if c.Bar != 12345 {
+		t.Error(c.Bar)
+	}
+	if c.Baz.SampleRate != 48000 {
+		t.Error(c.Baz.SampleRate)
+	}
+
+	if err := FileReader(c).Set(path.Join(dir, "2.json")); err != nil {
+		t.Error(err)
+	}
+}
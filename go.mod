@@ -2,7 +2,10 @@ module import.name/confi
 
 go 1.13
 
-require github.com/naoina/toml v0.1.1
+require (
+	github.com/naoina/toml v0.1.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/kylelemons/godebug v1.1.0 // indirect
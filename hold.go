@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Holder of a configuration object which may be accessed concurrently.  The
+// current object is an immutable snapshot: modifications are applied to a
+// deep copy, which is interpolated, validated and then published atomically.
+//
+// The holder keeps the uninterpolated configuration which modifications are
+// applied to, so escaped references remain escaped and references are
+// resolved again after each modification.  See Interpolate.
+type Holder struct {
+	value     atomic.Value
+	mu        sync.Mutex  // Serializes modifications.
+	raw       interface{} // Uninterpolated configuration.
+	callbacks []func(oldConfig, newConfig interface{})
+}
+
+// NewHolder with an initial configuration object.  The object must not be
+// modified after this.  It is published as is, and it is also the basis of
+// modifications, so it should not have been interpolated.
+func NewHolder(config interface{}) *Holder {
+	h := &Holder{raw: config}
+	h.value.Store(config)
+	return h
+}
+
+// Load the current configuration object.  It must not be modified.
+func (h *Holder) Load() interface{} {
+	return h.value.Load()
+}
+
+// OnChange registers a function which is called after a new configuration
+// object has been published.  Callbacks are called sequentially, and they
+// must not modify the holder.
+func (h *Holder) OnChange(f func(oldConfig, newConfig interface{})) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.callbacks = append(h.callbacks, f)
+}
+
+// Store a new configuration object, replacing the current one without
+// interpolation or validation.  The object must not be modified after this.
+// It is also the basis of subsequent modifications.
+func (h *Holder) Store(config interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.raw = config
+	h.publish(config)
+}
+
+// Update applies a function to a copy of the uninterpolated configuration
+// object.  If the function, interpolation and validation succeed, an
+// interpolated copy is published.
+func (h *Holder) Update(f func(config interface{}) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	raw := Clone(h.raw)
+
+	if err := f(raw); err != nil {
+		return err
+	}
+
+	config := Clone(raw)
+
+	if err := Interpolate(config); err != nil {
+		return err
+	}
+	if err := Validate(config); err != nil {
+		return err
+	}
+
+	h.raw = raw
+	h.publish(config)
+	return nil
+}
+
+// Assign a value to a copy of the configuration.  See Update and Assign.
+func (h *Holder) Assign(expr string) error {
+	return h.Update(func(config interface{}) error {
+		return assign(config, expr, false)
+	})
+}
+
+// Read TOML into a copy of the configuration.  See Update and Read.
+func (h *Holder) Read(r io.Reader) error {
+	return h.Update(func(config interface{}) error {
+		return read(r, config, false)
+	})
+}
+
+// ReadFile into a copy of the configuration.  The file format is chosen based
+// on the filename extension.  See Update and RegisterFormat.
+func (h *Holder) ReadFile(filename string) error {
+	return h.Update(func(config interface{}) error {
+		return readFormatFile(filename, config, false, nil)
+	})
+}
+
+// Apply a buffer to a copy of the configuration.  See Update and Buffer.Apply.
+func (h *Holder) Apply(b *Buffer) error {
+	return h.Update(func(config interface{}) error {
+		return b.flushEntries(config, false)
+	})
+}
+
+func (h *Holder) publish(config interface{}) {
+	old := h.value.Load()
+	h.value.Store(config)
+
+	for _, f := range h.callbacks {
+		f(old, config)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHolder(t *testing.T) {
+	initial := newTestConfig()
+	h := NewHolder(initial)
+
+	var changes int
+	h.OnChange(func(oldConfig, newConfig interface{}) {
+		changes++
+		if oldConfig == newConfig {
+			t.Error("same object")
+		}
+	})
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				c := h.Load().(*testConfig)
+				_ = c.Bar
+				_ = c.Ext["a"].(*testExtA).Average
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := h.Assign("ext.a.average=123"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := h.Read(strings.NewReader("bar = 12345\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	close(done)
+	wg.Wait()
+
+	if initial.Bar != 67890 || initial.Ext["a"].(*testExtA).Average != 0 {
+		t.Error("initial object was modified")
+	}
+
+	c := h.Load().(*testConfig)
+	if c.Bar != 12345 || c.Ext["a"].(*testExtA).Average != 123 {
+		t.Error(c.Bar, c.Ext["a"])
+	}
+
+	if err := h.Assign("bar=x"); err == nil {
+		t.Error("invalid assignment")
+	}
+	if h.Load() != c {
+		t.Error("failed update was published")
+	}
+
+	if changes != 101 {
+		t.Error(changes)
+	}
+}
+
+func TestHolderValidate(t *testing.T) {
+	h := NewHolder(newTestValidConfig())
+
+	if err := h.Update(func(config interface{}) error {
+		config.(*testValidConfig).Name = ""
+		return nil
+	}); err == nil {
+		t.Error("invalid update")
+	}
+	if h.Load().(*testValidConfig).Name != "test" {
+		t.Error(h.Load())
+	}
+}
+
+func TestHolderInterpolate(t *testing.T) {
+	h := NewHolder(new(testInterpolateConfig))
+
+	b := NewBuffer()
+	b.Assigner().Set("paths.root=/srv")
+	b.Assigner().Set("paths.data=${paths.root}/data")
+	b.Assigner().Set("literal=$${HOME}")
+
+	if err := h.Apply(b); err != nil {
+		t.Fatal(err)
+	}
+
+	c := h.Load().(*testInterpolateConfig)
+	if c.Paths.Data != "/srv/data" || c.Literal != "${HOME}" {
+		t.Error(c.Paths.Data, c.Literal)
+	}
+
+	b = NewBuffer()
+	b.Assigner().Set("paths.root=/var")
+
+	if err := h.Apply(b); err != nil {
+		t.Fatal(err)
+	}
+
+	c = h.Load().(*testInterpolateConfig)
+	if c.Paths.Data != "/var/data" || c.Literal != "${HOME}" {
+		t.Error(c.Paths.Data, c.Literal)
+	}
+}
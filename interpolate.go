@@ -0,0 +1,223 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Interpolate references in the string values of the configuration.
+// "${path.to.key}" is replaced with the value of another setting, and
+// "${env:NAME}" with the value of an environment variable.  "$${" produces a
+// literal "${".  Referenced strings are interpolated first; reference cycles
+// are errors.  Secrets are neither interpolated nor referenceable.  Errors are
+// of type *Error.
+//
+// Buffer.Flush calls Interpolate after all sources have been applied.
+// Escaped references are expanded if a configuration is interpolated again;
+// Holder avoids that by interpolating copies.
+func Interpolate(config interface{}) (err error) {
+	defer func() {
+		err = asError(recover())
+	}()
+
+	in := interpolator{
+		values: make(map[string]*interpolated),
+	}
+	in.collect("", reflect.ValueOf(config))
+
+	for _, path := range in.paths {
+		in.resolve(path, nil)
+	}
+	return
+}
+
+type interpolated struct {
+	node   reflect.Value // Settable string, or invalid if repr is final.
+	custom reflect.Value // Custom value which is formatted when referenced.
+	repr   string
+	secret bool
+	state  int // 0 = pending, 1 = resolving, 2 = done
+}
+
+type interpolator struct {
+	paths  []string
+	values map[string]*interpolated
+}
+
+func (in *interpolator) collect(path string, node reflect.Value) {
+	if node.Kind() == reflect.Interface {
+		node = node.Elem()
+	}
+	if node.Kind() == reflect.Ptr {
+		if node.IsNil() {
+			return
+		}
+		node = node.Elem()
+	}
+
+	if isCustomType(node.Type()) {
+		in.values[path] = &interpolated{custom: node, state: 2}
+		return
+	}
+
+	switch node.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		in.values[path] = &interpolated{repr: fmt.Sprint(node.Interface()), state: 2}
+
+	case reflect.String:
+		in.add(path, node)
+
+	case reflect.Slice:
+		switch node.Type().Elem().Kind() {
+		case reflect.String, reflect.Struct:
+			for i := 0; i < node.Len(); i++ {
+				in.collect(joinPath(path, strconv.Itoa(i)), node.Index(i))
+			}
+		}
+
+	case reflect.Map:
+		for _, key := range reflectMapKeyStrings(node) {
+			k := key
+			if strings.Contains(k, ".") {
+				k = fmt.Sprintf("%q", k)
+			}
+			in.collect(joinPath(path, k), node.MapIndex(reflect.ValueOf(key)))
+		}
+
+	case reflect.Struct:
+		for i := 0; i < node.Type().NumField(); i++ {
+			value := node.Field(i)
+			if !value.CanInterface() {
+				continue
+			}
+
+			key, ok := fieldKey(node.Type().Field(i))
+			if !ok {
+				continue
+			}
+
+			p := path
+			if key != "" {
+				p = joinPath(path, key)
+			}
+
+			in.collect(p, value)
+		}
+	}
+}
+
+func (in *interpolator) add(path string, node reflect.Value) {
+	x := &interpolated{repr: node.String(), secret: isSecret(node)}
+	if node.CanSet() && !x.secret {
+		x.node = node
+		in.paths = append(in.paths, path)
+	} else {
+		x.state = 2
+	}
+
+	in.values[path] = x
+}
+
+// resolve the value of a path.  chain lists the paths being resolved.
+func (in *interpolator) resolve(path string, chain []string) string {
+	x, found := in.values[path]
+	if !found {
+		panic(unknownKeyError("unknown config key"))
+	}
+	if x.secret {
+		panic(fmt.Errorf("secret cannot be referenced: %s", path))
+	}
+
+	switch x.state {
+	case 1:
+		panic(fmt.Errorf("reference cycle: %s -> %s", strings.Join(chain, " -> "), path))
+
+	case 2:
+		if x.custom.IsValid() {
+			x.repr = formatCustom(x.custom)
+			x.custom = reflect.Value{}
+		}
+		return x.repr
+	}
+
+	x.state = 1
+
+	func() {
+		defer func() {
+			if x := recover(); x != nil {
+				panic(Error{Path: path}.wrap(asError(x)))
+			}
+		}()
+
+		x.repr = in.expand(x.repr, append(chain, path))
+	}()
+
+	x.node.SetString(x.repr)
+	x.state = 2
+	return x.repr
+}
+
+func (in *interpolator) expand(s string, chain []string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	var b strings.Builder
+
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+
+		b.WriteString(s[:i])
+		s = s[i+2:]
+
+		j := strings.IndexByte(s, '}')
+		if j < 0 {
+			panic(errors.New("unterminated reference"))
+		}
+		ref := s[:j]
+		s = s[j+1:]
+
+		if strings.HasPrefix(ref, "env:") {
+			name := ref[4:]
+			value, found := os.LookupEnv(name)
+			if !found {
+				panic(fmt.Errorf("environment variable not set: %s", name))
+			}
+			b.WriteString(value)
+		} else {
+			func() {
+				defer func() {
+					if x := recover(); x != nil {
+						if isUnknownKey(x) {
+							panic(fmt.Errorf("unknown reference: %s", ref))
+						}
+						panic(x)
+					}
+				}()
+
+				b.WriteString(in.resolve(ref, chain))
+			}()
+		}
+	}
+
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testInterpolateConfig struct {
+	Paths struct {
+		Root string
+		Data string
+	}
+	LogDir  string
+	Port    int
+	Address string
+	Home    string
+	Literal string
+	Args    []string
+}
+
+func TestInterpolate(t *testing.T) {
+	os.Setenv("CONFI_TEST_HOME", "/home/test")
+	defer os.Unsetenv("CONFI_TEST_HOME")
+
+	c := new(testInterpolateConfig)
+
+	err := Read(strings.NewReader(`
+logdir = "${paths.data}/log"
+port = 8080
+address = "localhost:${port}"
+home = "${env:CONFI_TEST_HOME}"
+literal = "$${paths.root} costs $5"
+args = ["--dir=${paths.root}", "plain"]
+
+[paths]
+root = "/srv"
+data = "${paths.root}/data"
+`), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Interpolate(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Paths.Data != "/srv/data" {
+		t.Error(c.Paths.Data)
+	}
+	if c.LogDir != "/srv/data/log" {
+		t.Error(c.LogDir)
+	}
+	if c.Address != "localhost:8080" {
+		t.Error(c.Address)
+	}
+	if c.Home != "/home/test" {
+		t.Error(c.Home)
+	}
+	if c.Literal != "${paths.root} costs $5" {
+		t.Error(c.Literal)
+	}
+	if !reflect.DeepEqual(c.Args, []string{"--dir=/srv", "plain"}) {
+		t.Error(c.Args)
+	}
+}
+
+func TestInterpolateBuffer(t *testing.T) {
+	c := new(testInterpolateConfig)
+
+	b := NewBuffer()
+	b.Assigner().Set("logdir=${paths.root}/log")
+	b.Assigner().Set("paths.root=/var")
+
+	if err := b.Apply(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.LogDir != "/var/log" {
+		t.Error(c.LogDir)
+	}
+}
+
+func TestInterpolateMapKey(t *testing.T) {
+	c := map[string]interface{}{
+		"example.net": &testInterpolateConfig{Port: 443},
+		"local":       &testInterpolateConfig{Address: `${"example.net".port}`},
+	}
+
+	if err := Interpolate(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := c["local"].(*testInterpolateConfig).Address; s != "443" {
+		t.Error(s)
+	}
+}
+
+func TestInterpolateError(t *testing.T) {
+	for expr, path := range map[string]string{
+		"logdir=${logdir}":          "logdir",
+		"logdir=${nonexistent}":     "logdir",
+		"logdir=${paths.root":       "logdir",
+		"home=${env:CONFI_TEST_NX}": "home",
+	} {
+		c := new(testInterpolateConfig)
+		MustAssign(c, expr)
+
+		err := Interpolate(c)
+		if e, ok := err.(*Error); !ok || e.Path != path {
+			t.Errorf("%s: %v", expr, err)
+		}
+	}
+
+	c := new(testInterpolateConfig)
+	MustAssign(c, "paths.root=${paths.data}")
+	MustAssign(c, "paths.data=${logdir}")
+	MustAssign(c, "logdir=${paths.root}")
+
+	err := Interpolate(c)
+	if err == nil || !strings.Contains(err.Error(), "reference cycle: paths.root -> paths.data -> logdir -> paths.root") {
+		t.Error(err)
+	}
+}
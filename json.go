@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+)
+
+// ReadJSON into the configuration.  The document must be a JSON object.
+// Arrays of objects are appended to struct slices, like TOML table arrays.
+func ReadJSON(r io.Reader, config interface{}) error {
+	return readJSON(r, config, false)
+}
+
+func readJSON(r io.Reader, config interface{}, ignoreUnknown bool) (err error) {
+	var fields map[string]interface{}
+
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	if err = d.Decode(&fields); err != nil {
+		return
+	}
+
+	defer func() {
+		err = asError(recover())
+	}()
+
+	setJSONFields(config, "", fields, ignoreUnknown)
+	return
+}
+
+// ReadJSONFile containing JSON into the configuration.
+func ReadJSONFile(filename string, config interface{}) error {
+	return readJSONFile(filename, config, false)
+}
+
+func readJSONFile(filename string, config interface{}, ignoreUnknown bool) (err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	return readJSON(f, config, ignoreUnknown)
+}
+
+func setJSONFields(config interface{}, path string, fields map[string]interface{}, ignoreUnknown bool) {
+	for k, v := range fields {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+
+		switch x := v.(type) {
+		case bool:
+			setFromString(config, p, fmt.Sprint(x), ignoreUnknown)
+
+		case json.Number:
+			setFromString(config, p, x.String(), ignoreUnknown)
+
+		case string:
+			setFromString(config, p, x, ignoreUnknown)
+
+		case []interface{}:
+			if tables, ok := jsonObjects(x); ok {
+				appendJSONObjects(config, p, tables, ignoreUnknown)
+			} else if len(x) == 0 && isStructSlice(config, p) {
+				// Nothing to append.
+			} else {
+				data, err := json.Marshal(x)
+				if err != nil {
+					panic(fmt.Errorf("%s: %v", p, err))
+				}
+				setFromString(config, p, string(data), ignoreUnknown)
+			}
+
+		case map[string]interface{}:
+			setJSONFields(config, p, x, ignoreUnknown)
+
+		default:
+			panic(fmt.Errorf("%s: value type not supported: %#v", p, v))
+		}
+	}
+}
+
+func jsonObjects(array []interface{}) (objects []map[string]interface{}, ok bool) {
+	if len(array) == 0 {
+		return
+	}
+
+	for _, v := range array {
+		x, isObject := v.(map[string]interface{})
+		if !isObject {
+			return nil, false
+		}
+		objects = append(objects, x)
+	}
+
+	return objects, true
+}
+
+func appendJSONObjects(config interface{}, path string, objects []map[string]interface{}, ignoreUnknown bool) {
+	node := lookup(config, path)
+	n := node.Len()
+	for i, x := range objects {
+		setJSONFields(config, fmt.Sprintf("%s.%d", path, n+i), x, ignoreUnknown)
+	}
+}
+
+// WriteJSON writes the configuration as JSON.
+func WriteJSON(w io.Writer, config interface{}) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(sanitizeContainer(make(map[string]interface{}), reflect.ValueOf(config).Elem()))
+}
+
+// WriteJSONFile containing the configuration as JSON.
+func WriteJSONFile(filename string, config interface{}) (err error) {
+	b := bytes.NewBuffer(nil)
+
+	if err = WriteJSON(b, config); err != nil {
+		return
+	}
+
+	return ioutil.WriteFile(filename, b.Bytes(), 0666)
+}
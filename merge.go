@@ -0,0 +1,250 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// SliceMerge strategy.
+type SliceMerge int
+
+// Slice merge strategies.  MergeByIndex and MergeByKey apply only to struct
+// slices.
+const (
+	ReplaceSlice SliceMerge = iota // Replace the whole slice.
+	AppendSlice                    // Append the source elements.
+	MergeByIndex                   // Merge elements with the same index.
+	MergeByKey                     // Merge elements with the same key field value.
+)
+
+// MergeOptions customize Merge.  The zero value replaces slices, and treats
+// zero values as defaults.
+type MergeOptions struct {
+	Strings SliceMerge // Strategy for non-struct slices: ReplaceSlice or AppendSlice.
+	Structs SliceMerge // Strategy for struct slices.
+	Key     string     // Configuration key of the struct field used by MergeByKey.
+
+	// Defaults is a configuration object of the same type, containing the
+	// default values.  Source values which are equal to the defaults are not
+	// merged.
+	Defaults interface{}
+}
+
+// Merge overlays the non-default values of a source configuration object onto
+// a destination object of the same type.  Map entries and struct slice
+// elements which don't exist in the destination are cloned from the source.
+// Options may be nil.
+func Merge(dst, src interface{}, opt *MergeOptions) (err error) {
+	defer func() {
+		err = asError(recover())
+	}()
+
+	if opt == nil {
+		opt = new(MergeOptions)
+	}
+
+	d := reflect.ValueOf(dst)
+	s := reflect.ValueOf(src)
+	if d.Type() != s.Type() {
+		panic(fmt.Errorf("configuration types differ: %s and %s", d.Type(), s.Type()))
+	}
+	if d.Kind() != reflect.Ptr || d.IsNil() {
+		panic(errors.New("destination must be a non-nil pointer"))
+	}
+
+	def := reflect.Zero(s.Type())
+	if opt.Defaults != nil {
+		def = reflect.ValueOf(opt.Defaults)
+		if def.Type() != s.Type() {
+			panic(fmt.Errorf("defaults type differs: %s", def.Type()))
+		}
+	}
+
+	m := merger{opt}
+	m.merge("", d.Elem(), indirectValue(s), indirectValue(def))
+	return
+}
+
+type merger struct {
+	opt *MergeOptions
+}
+
+// merge src into dst, which must be settable.  def may be invalid (no
+// default).
+func (m merger) merge(path string, dst, src, def reflect.Value) {
+	if def.IsValid() && reflect.DeepEqual(src.Interface(), def.Interface()) {
+		return
+	}
+
+	if isCustomType(src.Type()) {
+		if src.Kind() != reflect.Ptr || !src.IsNil() {
+			dst.Set(deepCopy(src))
+		}
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(deepCopy(src))
+			return
+		}
+		m.merge(path, dst.Elem(), src.Elem(), indirectValue(def))
+
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			field := src.Type().Field(i)
+
+			key, ok := fieldKey(field)
+			if !ok {
+				continue
+			}
+
+			p := path
+			if key != "" {
+				p = joinPath(path, key)
+			}
+
+			var d reflect.Value
+			if def.IsValid() {
+				d = def.Field(i)
+			}
+
+			m.merge(p, dst.Field(i), src.Field(i), d)
+		}
+
+	case reflect.Map:
+		m.mergeMap(path, dst, src, def)
+
+	case reflect.Slice:
+		if src.Type().Elem().Kind() == reflect.Struct {
+			m.mergeStructs(path, dst, src)
+		} else {
+			m.mergeSlice(path, dst, src)
+		}
+
+	default:
+		dst.Set(src)
+	}
+}
+
+func (m merger) mergeMap(path string, dst, src, def reflect.Value) {
+	if src.IsNil() {
+		return
+	}
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+	}
+
+	for _, key := range src.MapKeys() {
+		s := src.MapIndex(key)
+		d := dst.MapIndex(key)
+
+		var x reflect.Value
+		if def.IsValid() && !def.IsNil() {
+			x = def.MapIndex(key)
+		}
+
+		if !d.IsValid() {
+			if !x.IsValid() || !reflect.DeepEqual(s.Interface(), x.Interface()) {
+				dst.SetMapIndex(key, deepCopy(s))
+			}
+			continue
+		}
+
+		// Struct pointers can be merged in place.
+		if d, s := indirectValue(d), indirectValue(s); d.Kind() == reflect.Struct && d.CanSet() && d.Type() == s.Type() {
+			if x.IsValid() {
+				x = indirectValue(x)
+				if x.Type() != s.Type() {
+					x = reflect.Value{}
+				}
+			}
+			m.merge(joinPath(path, key.String()), d, s, x)
+			continue
+		}
+
+		if !x.IsValid() || !reflect.DeepEqual(s.Interface(), x.Interface()) {
+			dst.SetMapIndex(key, deepCopy(s))
+		}
+	}
+}
+
+func (m merger) mergeSlice(path string, dst, src reflect.Value) {
+	switch m.opt.Strings {
+	case ReplaceSlice:
+		dst.Set(deepCopy(src))
+
+	case AppendSlice:
+		dst.Set(reflect.AppendSlice(dst, deepCopy(src)))
+
+	default:
+		panic(fmt.Errorf("%s: unsupported slice merge strategy: %d", path, m.opt.Strings))
+	}
+}
+
+func (m merger) mergeStructs(path string, dst, src reflect.Value) {
+	switch m.opt.Structs {
+	case ReplaceSlice:
+		dst.Set(deepCopy(src))
+
+	case AppendSlice:
+		dst.Set(reflect.AppendSlice(dst, deepCopy(src)))
+
+	case MergeByIndex:
+		zero := reflect.Zero(src.Type().Elem())
+		for i := 0; i < src.Len(); i++ {
+			if i < dst.Len() {
+				m.merge(fmt.Sprintf("%s.%d", path, i), dst.Index(i), src.Index(i), zero)
+			} else {
+				dst.Set(reflect.Append(dst, deepCopy(src.Index(i))))
+			}
+		}
+
+	case MergeByKey:
+		index, found := findField(src.Type().Elem(), m.opt.Key)
+		if !found {
+			panic(fmt.Errorf("%s: key field not found: %q", path, m.opt.Key))
+		}
+
+		zero := reflect.Zero(src.Type().Elem())
+
+	srcLoop:
+		for i := 0; i < src.Len(); i++ {
+			key := src.Index(i).FieldByIndex(index).Interface()
+
+			for j := 0; j < dst.Len(); j++ {
+				if reflect.DeepEqual(dst.Index(j).FieldByIndex(index).Interface(), key) {
+					m.merge(fmt.Sprintf("%s.%d", path, j), dst.Index(j), src.Index(i), zero)
+					continue srcLoop
+				}
+			}
+
+			dst.Set(reflect.Append(dst, deepCopy(src.Index(i))))
+		}
+
+	default:
+		panic(fmt.Errorf("%s: unsupported slice merge strategy: %d", path, m.opt.Structs))
+	}
+}
+
+func indirectValue(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Zero(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+	return v
+}
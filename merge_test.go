@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testMergeItem struct {
+	Name  string
+	Value int
+	Tags  []string
+}
+
+type testMergeConfig struct {
+	Host  string
+	Port  int
+	Tags  []string
+	Items []testMergeItem
+	Ptr   *testMergeItem
+	Ext   map[string]interface{}
+}
+
+func newTestMergeConfig() *testMergeConfig {
+	return &testMergeConfig{
+		Host: "localhost",
+		Port: 80,
+		Tags: []string{"default"},
+		Items: []testMergeItem{
+			{Name: "a", Value: 1},
+			{Name: "b", Value: 2},
+		},
+		Ext: map[string]interface{}{
+			"x": &testExtA{Average: 1},
+		},
+	}
+}
+
+func TestMergeDefaults(t *testing.T) {
+	dst := newTestMergeConfig()
+	dst.Host = "example.net"
+
+	src := newTestMergeConfig()
+	src.Port = 8080
+	src.Ptr = &testMergeItem{Name: "p"}
+
+	if err := Merge(dst, src, &MergeOptions{Defaults: newTestMergeConfig()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if dst.Host != "example.net" {
+		t.Error(dst.Host)
+	}
+	if dst.Port != 8080 {
+		t.Error(dst.Port)
+	}
+	if dst.Ptr == nil || dst.Ptr.Name != "p" || dst.Ptr == src.Ptr {
+		t.Error(dst.Ptr)
+	}
+	if !reflect.DeepEqual(dst.Tags, []string{"default"}) {
+		t.Error(dst.Tags)
+	}
+}
+
+func TestMergeZeroDefaults(t *testing.T) {
+	dst := newTestMergeConfig()
+
+	src := new(testMergeConfig)
+	src.Host = "example.net"
+	src.Ext = map[string]interface{}{
+		"x": &testExtA{Average: 5},
+		"y": &testExtB{Beverage: 3},
+	}
+
+	if err := Merge(dst, src, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if dst.Host != "example.net" || dst.Port != 80 {
+		t.Error(dst.Host, dst.Port)
+	}
+	if !reflect.DeepEqual(dst.Tags, []string{"default"}) || len(dst.Items) != 2 {
+		t.Error(dst.Tags, dst.Items)
+	}
+	if x := dst.Ext["x"].(*testExtA); x.Average != 5 {
+		t.Error(x)
+	}
+	if y := dst.Ext["y"].(*testExtB); y.Beverage != 3 || y == src.Ext["y"] {
+		t.Error(y)
+	}
+}
+
+func TestMergeSlices(t *testing.T) {
+	src := &testMergeConfig{
+		Tags: []string{"extra"},
+		Items: []testMergeItem{
+			{Name: "b", Tags: []string{"t"}},
+			{Name: "c", Value: 3},
+		},
+	}
+
+	for _, test := range []struct {
+		opt   MergeOptions
+		tags  []string
+		items []testMergeItem
+	}{
+		{
+			MergeOptions{},
+			[]string{"extra"},
+			[]testMergeItem{{"b", 0, []string{"t"}}, {"c", 3, nil}},
+		},
+		{
+			MergeOptions{Strings: AppendSlice, Structs: AppendSlice},
+			[]string{"default", "extra"},
+			[]testMergeItem{{"a", 1, nil}, {"b", 2, nil}, {"b", 0, []string{"t"}}, {"c", 3, nil}},
+		},
+		{
+			MergeOptions{Structs: MergeByIndex},
+			[]string{"extra"},
+			[]testMergeItem{{"b", 1, []string{"t"}}, {"c", 3, nil}},
+		},
+		{
+			MergeOptions{Structs: MergeByKey, Key: "name"},
+			[]string{"extra"},
+			[]testMergeItem{{"a", 1, nil}, {"b", 2, []string{"t"}}, {"c", 3, nil}},
+		},
+	} {
+		dst := newTestMergeConfig()
+
+		if err := Merge(dst, src, &test.opt); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(dst.Tags, test.tags) {
+			t.Errorf("%#v: tags: %q", test.opt, dst.Tags)
+		}
+		if !reflect.DeepEqual(dst.Items, test.items) {
+			t.Errorf("%#v: items: %v", test.opt, dst.Items)
+		}
+	}
+
+	if len(src.Items) != 2 || src.Items[0].Value != 0 {
+		t.Error("source modified:", src.Items)
+	}
+}
+
+func TestMergeError(t *testing.T) {
+	if err := Merge(newTestMergeConfig(), newTestConfig(), nil); err == nil {
+		t.Error("type mismatch not detected")
+	}
+
+	opt := &MergeOptions{Structs: MergeByKey, Key: "nonexistent"}
+	if err := Merge(newTestMergeConfig(), newTestMergeConfig(), opt); err == nil {
+		t.Error("missing key field not detected")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Naming strategy derives configuration keys from Go field names.  The
+// built-in strategies delimit the words of a field name by case changes and
+// underscores: "HTTPSampleRate" consists of "HTTP", "Sample" and "Rate".
+type Naming func(fieldName string) string
+
+// KeyNaming is used for fields which don't have a confi tag.  It must not be
+// changed while configuration objects are being accessed.
+var KeyNaming Naming = LowerCase
+
+// LowerCase naming: "httpsamplerate".
+func LowerCase(name string) string {
+	return strings.ToLower(name)
+}
+
+// SnakeCase naming: "http_sample_rate".
+func SnakeCase(name string) string {
+	return strings.ToLower(strings.Join(splitWords(name), "_"))
+}
+
+// KebabCase naming: "http-sample-rate".
+func KebabCase(name string) string {
+	return strings.ToLower(strings.Join(splitWords(name), "-"))
+}
+
+// CamelCase naming: "httpSampleRate".
+func CamelCase(name string) string {
+	words := splitWords(name)
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if i > 0 {
+			w = strings.ToUpper(w[:1]) + w[1:]
+		}
+		words[i] = w
+	}
+	return strings.Join(words, "")
+}
+
+func splitWords(name string) (words []string) {
+	runes := []rune(name)
+	begin := 0
+
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			if i > begin {
+				words = append(words, string(runes[begin:i]))
+			}
+			begin = i + 1
+
+		case i > begin && unicode.IsUpper(r):
+			prev := runes[i-1]
+			if !unicode.IsUpper(prev) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				words = append(words, string(runes[begin:i]))
+				begin = i
+			}
+		}
+	}
+
+	if len(runes) > begin {
+		words = append(words, string(runes[begin:]))
+	}
+	return
+}
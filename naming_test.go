@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNaming(t *testing.T) {
+	for _, spec := range []struct {
+		name  string
+		lower string
+		snake string
+		kebab string
+		camel string
+	}{
+		{"Foo", "foo", "foo", "foo", "foo"},
+		{"SampleRate", "samplerate", "sample_rate", "sample-rate", "sampleRate"},
+		{"HTTPServer", "httpserver", "http_server", "http-server", "httpServer"},
+		{"ServerID", "serverid", "server_id", "server-id", "serverId"},
+		{"Key2b", "key2b", "key2b", "key2b", "key2b"},
+		{"Key_a", "key_a", "key_a", "key-a", "keyA"},
+		{"X", "x", "x", "x", "x"},
+	} {
+		if s := LowerCase(spec.name); s != spec.lower {
+			t.Errorf("LowerCase(%q) = %q", spec.name, s)
+		}
+		if s := SnakeCase(spec.name); s != spec.snake {
+			t.Errorf("SnakeCase(%q) = %q", spec.name, s)
+		}
+		if s := KebabCase(spec.name); s != spec.kebab {
+			t.Errorf("KebabCase(%q) = %q", spec.name, s)
+		}
+		if s := CamelCase(spec.name); s != spec.camel {
+			t.Errorf("CamelCase(%q) = %q", spec.name, s)
+		}
+	}
+}
+
+func TestKeyNaming(t *testing.T) {
+	defer func(orig Naming) { KeyNaming = orig }(KeyNaming)
+	KeyNaming = KebabCase
+
+	var c struct {
+		AudioConfig struct {
+			SampleRate int
+			BitDepth   int `confi:"bits"`
+		}
+	}
+
+	if err := Read(strings.NewReader(`[audio-config]
+sample-rate = 48000
+bits = 24
+`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.AudioConfig.SampleRate != 48000 {
+		t.Error(c.AudioConfig.SampleRate)
+	}
+	if c.AudioConfig.BitDepth != 24 {
+		t.Error(c.AudioConfig.BitDepth)
+	}
+
+	if ss := Settings(&c); !reflect.DeepEqual(ss, []Setting{
+		{"audio-config.sample-rate", reflect.TypeOf(0), "48000", ""},
+		{"audio-config.bits", reflect.TypeOf(0), "24", ""},
+	}) {
+		t.Errorf("%#v", ss)
+	}
+
+	b := new(bytes.Buffer)
+	if err := Write(b, &c); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); !strings.Contains(s, "sample-rate = 48000") {
+		t.Error(s)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// Secret is a string setting which is never displayed.  It is redacted by
+// PrintSettings, Tracker.Print, Diff and error messages, and omitted by Write.
+//
+// When a secret is set from a string representation, a value of the form
+// "@/path/to/file" or "file:/path/to/file" is replaced with the contents of
+// the file.  A trailing newline is removed.
+type Secret string
+
+// String returns a placeholder instead of the value.
+func (Secret) String() string {
+	return redacted
+}
+
+// GoString returns a placeholder instead of the value.
+func (Secret) GoString() string {
+	return "confi.Secret(" + redacted + ")"
+}
+
+const redacted = "[redacted]"
+
+var secretType = reflect.TypeOf(Secret(""))
+
+func isSecret(value reflect.Value) bool {
+	return value.Type() == secretType
+}
+
+// redact s if it is the representation of a secret value.
+func redact(value reflect.Value, s string) string {
+	if isSecret(value) {
+		return redacted
+	}
+	return s
+}
+
+// readSecret resolves a file reference.
+func readSecret(repr string) string {
+	var filename string
+
+	switch {
+	case strings.HasPrefix(repr, "@"):
+		filename = repr[1:]
+
+	case strings.HasPrefix(repr, "file:"):
+		filename = repr[5:]
+
+	default:
+		return repr
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		panic(err)
+	}
+
+	s := string(data)
+	s = strings.TrimSuffix(s, "\n")
+	s = strings.TrimSuffix(s, "\r")
+	return s
+}
+
+// redactExpr replaces the value of an assignment expression.
+func redactExpr(expr string) string {
+	if i := strings.Index(expr, "="); i >= 0 {
+		return expr[:i+1] + redacted
+	}
+	return expr
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+type testSecretConfig struct {
+	DB struct {
+		User     string
+		Password Secret `pattern:"^[a-z]+$"`
+	}
+}
+
+func TestSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "password")
+	if err := ioutil.WriteFile(filename, []byte("hunter\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, repr := range []string{"@" + filename, "file:" + filename, "hunter"} {
+		c := new(testSecretConfig)
+		tracker := Track(c)
+
+		MustAssign(c, "db.user=admin")
+		MustAssign(c, "db.password="+repr)
+		tracker.Stop()
+
+		if c.DB.Password != "hunter" {
+			t.Errorf("%s: %q", repr, string(c.DB.Password))
+		}
+
+		b := bytes.NewBuffer(nil)
+		PrintSettings(b, c)
+		tracker.Print(b)
+		fmt.Fprintf(b, "%v %+v %#v\n", c.DB.Password, c, c.DB)
+		if err := Write(b, c); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteJSON(b, c); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteYAML(b, c); err != nil {
+			t.Fatal(err)
+		}
+
+		if s := b.String(); strings.Contains(s, "hunter") || !strings.Contains(s, "admin") {
+			t.Error(s)
+		}
+	}
+
+	if err := Assign(new(testSecretConfig), "db.password=@"+path.Join(dir, "nonexistent")); err == nil {
+		t.Error("nonexistent file not detected")
+	}
+}
+
+func TestSecretRedacted(t *testing.T) {
+	a := new(testSecretConfig)
+	a.DB.Password = "old"
+
+	b := new(testSecretConfig)
+	b.DB.Password = "new"
+
+	changes := Diff(a, b)
+	if len(changes) != 1 || changes[0].Old != redacted || changes[0].New != redacted {
+		t.Error(changes)
+	}
+
+	err := Assign(new(testSecretConfig), "db.password=Hunter2")
+	if err == nil || strings.Contains(err.Error(), "Hunter2") {
+		t.Error(err)
+	}
+
+	c := new(testSecretConfig)
+	c.DB.Password = "${db.user}"
+	c.DB.User = "${db.password}"
+	if err := Interpolate(c); err == nil {
+		t.Error("secret reference not detected")
+	}
+	if c.DB.Password != "${db.user}" {
+		t.Error("secret interpolated")
+	}
+}
@@ -32,7 +32,13 @@ func Set(config interface{}, path string, value interface{}) (err error) {
 // type as the field.  Panic if the field doesn't exist or the types don't
 // match.
 func MustSet(config interface{}, path string, value interface{}) {
-	lookup(config, path).Set(reflect.ValueOf(value))
+	node, tag := lookupField(config, path)
+
+	x := reflect.New(node.Type()).Elem()
+	x.Set(reflect.ValueOf(value))
+	checkField(path, x, tag)
+	node.Set(x)
+	recordSource(config, path, isSecret(node))
 }
 
 // SetFromString sets a field of the configuration object.  The value
@@ -41,10 +47,17 @@ func MustSet(config interface{}, path string, value interface{}) {
 // Valid boolean representations are "true", "false", "yes", "no", "y", "n",
 // "on", and "off".
 //
-// If the representation of a string list is the empty string, the field will
-// be an empty list.  If the representation starts with "[", it is assumed to
-// be a JSON-encoded string array.  Otherwise the length will be one, and repr
-// will be the single item.
+// Types which implement encoding.TextUnmarshaler or flag.Value (either
+// directly or via pointer) are parsed using the UnmarshalText or Set method.
+//
+// Slices of the scalar types are supported.  If the representation of a slice
+// is the empty string, the field will be an empty slice.  If the
+// representation starts with "[", it is assumed to be a JSON-encoded array;
+// the items are parsed according to the element type.  Otherwise the length
+// will be one, and repr will be the single item.
+//
+// The value is checked against the field's validation tags (except
+// "required"); see Validate.
 func SetFromString(config interface{}, path string, repr string) (err error) {
 	defer func() {
 		err = asError(recover())
@@ -60,7 +73,34 @@ func SetFromString(config interface{}, path string, repr string) (err error) {
 //
 // See SetFromString for parsing rules.
 func MustSetFromString(config interface{}, path string, repr string) {
-	node := lookup(config, path)
+	field, tag := lookupField(config, path)
+
+	defer func() {
+		if x := recover(); x != nil {
+			panic(&Error{Path: path, Err: asError(x)})
+		}
+	}()
+
+	node := reflect.New(field.Type()).Elem()
+	setValueFromString(node, repr)
+
+	if err := checkValue(node, tag); err != nil {
+		panic(err)
+	}
+	field.Set(node)
+	recordSource(config, path, isSecret(field))
+}
+
+func checkField(path string, value reflect.Value, tag reflect.StructTag) {
+	if err := checkValue(value, tag); err != nil {
+		panic(&Error{Path: path, Err: err})
+	}
+}
+
+func setValueFromString(node reflect.Value, repr string) {
+	if setCustomFromString(node, repr) {
+		return
+	}
 
 	switch node.Kind() {
 	case reflect.Bool:
@@ -82,7 +122,7 @@ func MustSetFromString(config interface{}, path string, repr string) {
 		if node.Type() == durationType {
 			d, err := time.ParseDuration(repr)
 			if err != nil {
-				panic(fmt.Errorf("%s: %v", path, err))
+				panic(err)
 			}
 			node.SetInt(int64(d))
 		} else {
@@ -111,10 +151,13 @@ func MustSetFromString(config interface{}, path string, repr string) {
 		setFloatFromString(node, repr, 64)
 
 	case reflect.String:
+		if isSecret(node) {
+			repr = readSecret(repr)
+		}
 		node.SetString(repr)
 
 	case reflect.Slice:
-		if node.Type().Elem().Kind() == reflect.String {
+		if isScalarType(node.Type().Elem()) {
 			setSliceFromString(node, repr)
 			break
 		}
@@ -124,6 +167,16 @@ func MustSetFromString(config interface{}, path string, repr string) {
 	}
 }
 
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.String:
+		return true
+
+	default:
+		return false
+	}
+}
+
 func setBoolFromString(node reflect.Value, repr string) {
 	switch strings.ToLower(repr) {
 	case "false", "no", "n", "off":
@@ -162,49 +215,86 @@ func setFloatFromString(node reflect.Value, repr string, bitSize int) {
 }
 
 func setSliceFromString(node reflect.Value, repr string) {
-	var slice []string
+	var items []string
 
 	switch {
 	case repr == "":
 		// ok
 
 	case strings.HasPrefix(repr, "["):
-		if err := json.Unmarshal([]byte(repr), &slice); err != nil {
+		var array []interface{}
+
+		d := json.NewDecoder(strings.NewReader(repr))
+		d.UseNumber()
+		if err := d.Decode(&array); err != nil {
 			panic(err)
 		}
 
+		for _, x := range array {
+			switch x.(type) {
+			case string, json.Number, bool:
+				items = append(items, fmt.Sprint(x))
+
+			default:
+				panic(fmt.Errorf("unsupported array item: %#v", x))
+			}
+		}
+
 	default:
-		slice = []string{repr}
+		items = []string{repr}
 	}
 
-	node.Set(reflect.ValueOf(slice))
+	slice := reflect.Zero(node.Type())
+	if items != nil {
+		slice = reflect.MakeSlice(node.Type(), len(items), len(items))
+		for i, item := range items {
+			setValueFromString(slice.Index(i), item)
+		}
+	}
+
+	node.Set(slice)
 }
 
 // Assign a value to a field of the configuration object.  The field's path and
 // string representation are parsed from an expression of the form "path=repr".
 //
+// The configuration is validated after the assignment; see Validate.
+//
 // See SetFromString for parsing rules.
 func Assign(config interface{}, expr string) error {
-	return assign(config, expr, false)
+	if err := assign(config, expr, false); err != nil {
+		return err
+	}
+	return Validate(config)
 }
 
 func assign(config interface{}, expr string, ignoreUnknown bool) (err error) {
 	defer func() {
 		err = asError(recover())
 		if err != nil && ignoreUnknown {
-			if _, ok := err.(unknownKeyError); ok {
+			if isUnknownKey(err) {
 				err = nil
 			}
 		}
 	}()
 
-	MustAssign(config, expr)
+	mustAssign(config, expr)
 	return
 }
 
 // MustAssign is a panicking alternative to the Assign method.  It panics if
-// the field doesn't exist or parsing fails.
+// the field doesn't exist, parsing fails or the configuration is invalid.
 func MustAssign(config interface{}, expr string) {
+	mustAssign(config, expr)
+
+	if err := Validate(config); err != nil {
+		panic(err)
+	}
+}
+
+func mustAssign(config interface{}, expr string) {
+	defer withSource(config, Source{Kind: SourceAssignment, Expr: expr})()
+
 	tokens := strings.SplitN(expr, "=", 2)
 	if len(tokens) != 2 {
 		panic(fmt.Errorf("invalid assignment expression: %q", expr))
@@ -223,7 +313,27 @@ func Get(config interface{}, path string) (value interface{}, err error) {
 	return
 }
 
-func lookup(config interface{}, path string) (node reflect.Value) {
+func lookup(config interface{}, path string) reflect.Value {
+	node, _ := lookupField(config, path)
+	return node
+}
+
+// isStructSlice checks if path refers to a slice of structs.  False is returned
+// also if the path can't be resolved.
+func isStructSlice(config interface{}, path string) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	t := lookup(config, path).Type()
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct
+}
+
+// lookupField finds a node and the tag of the struct field which contains it.
+// The tag is empty if the node is a map or slice element.
+func lookupField(config interface{}, path string) (node reflect.Value, tag reflect.StructTag) {
 	node = reflect.ValueOf(config)
 
 	for _, nodeName := range splitPath(path) {
@@ -236,6 +346,8 @@ func lookup(config interface{}, path string) (node reflect.Value) {
 
 		var ok bool
 
+		tag = ""
+
 		switch node.Kind() {
 		case reflect.Map:
 			node = node.MapIndex(reflect.ValueOf(nodeName))
@@ -256,23 +368,73 @@ func lookup(config interface{}, path string) (node reflect.Value) {
 			}
 
 		case reflect.Struct:
-			field, found := node.Type().FieldByNameFunc(func(fieldName string) bool {
-				return strings.ToLower(fieldName) == nodeName
-			})
-			if found {
-				node = node.FieldByIndex(field.Index)
+			if index, found := findField(node.Type(), nodeName); found {
+				tag = node.Type().FieldByIndex(index).Tag
+				node = node.FieldByIndex(index)
 				ok = true
 			}
 		}
 
 		if !ok {
-			panic(unknownKeyError(fmt.Sprintf("unknown config key: %q", path)))
+			panic(&Error{Path: path, Err: unknownKeyError("unknown config key")})
+		}
+	}
+
+	return
+}
+
+// findField by key.  Fields of the struct itself take precedence over fields
+// promoted from embedded structs.
+func findField(t reflect.Type, key string) (index []int, found bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := fieldKey(t.Field(i)); ok && name == key {
+			return []int{i}, true
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, ok := fieldKey(field); ok && name == "" {
+			if sub, found := findField(indirectType(field.Type), key); found {
+				return append([]int{i}, sub...), true
+			}
 		}
 	}
 
 	return
 }
 
+// fieldKey determines the configuration key of a struct field.  The key is
+// specified using the "confi" tag, or it is derived from the field name
+// according to KeyNaming.  The key is empty for an embedded struct whose
+// fields are promoted.  The field is hidden if ok is false.
+func fieldKey(field reflect.StructField) (key string, ok bool) {
+	if field.PkgPath != "" {
+		return
+	}
+
+	switch tag := field.Tag.Get("confi"); tag {
+	case "-":
+		return
+
+	case "":
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			return "", true
+		}
+		return KeyNaming(field.Name), true
+
+	default:
+		return tag, true
+	}
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
 func splitPath(path string) (clean []string) {
 	crude := strings.Split(path, ".")
 
@@ -311,3 +473,11 @@ type unknownKeyError string
 
 func (x unknownKeyError) Error() string  { return string(x) }
 func (x unknownKeyError) String() string { return string(x) }
+
+func isUnknownKey(x interface{}) bool {
+	if e, ok := x.(*Error); ok {
+		x = e.Err
+	}
+	_, ok := x.(unknownKeyError)
+	return ok
+}
@@ -5,7 +5,9 @@
 package confi
 
 import (
+	"bytes"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -98,6 +100,20 @@ func TestSet(t *testing.T) {
 		t.Error(c.Baz.Interval)
 	}
 
+	if err := Set(c, "baz.sample_rate", 48000); err != nil {
+		t.Error(err)
+	}
+	if c.Baz.SampleRate != 48000 {
+		t.Error(c.Baz.SampleRate)
+	}
+
+	if err := Set(c, "baz.secret", "hidden"); err == nil {
+		t.Error("baz.secret")
+	}
+	if c.Baz.Secret != "" {
+		t.Error(c.Baz.Secret)
+	}
+
 	if err := Set(c, "ext.a.average", 123); err != nil {
 		t.Error(err)
 	}
@@ -130,3 +146,100 @@ func TestGet(t *testing.T) {
 		t.Error(x)
 	}
 }
+
+type testSliceConfig struct {
+	Ports   []uint16
+	Weights []float64
+	Flags   []bool
+	Backoff []time.Duration
+	Counts  []int
+}
+
+func TestSetSlices(t *testing.T) {
+	expect := testSliceConfig{
+		Ports:   []uint16{80, 443},
+		Weights: []float64{0.5, 1.5},
+		Flags:   []bool{true, false},
+		Backoff: []time.Duration{time.Second, 90 * time.Second},
+		Counts:  []int{1000},
+	}
+
+	c := new(testSliceConfig)
+	MustAssign(c, "ports=[80, 443]")
+	MustAssign(c, `weights=[0.5, "1.5"]`)
+	MustAssign(c, "flags=[true, false]")
+	MustAssign(c, `backoff=["1s", "1m30s"]`)
+	MustAssign(c, "counts=1000")
+	if !reflect.DeepEqual(*c, expect) {
+		t.Errorf("assign: %v", *c)
+	}
+
+	MustAssign(c, "counts=")
+	if c.Counts != nil {
+		t.Error(c.Counts)
+	}
+
+	for _, expr := range []string{"ports=[65536]", "counts=[1.5]", "flags=[1]", "counts=[[1]]"} {
+		if err := Assign(c, expr); err == nil {
+			t.Errorf("%s: no error", expr)
+		}
+	}
+
+	c = new(testSliceConfig)
+	err := Read(strings.NewReader(`ports = [80, 443]
+weights = [0.5, 1.5]
+flags = [true, false]
+backoff = ["1s", "1m30s"]
+counts = [1_000]
+`), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*c, expect) {
+		t.Errorf("toml: %v", *c)
+	}
+
+	c = new(testSliceConfig)
+	err = ReadJSON(strings.NewReader(`{"ports": [80, 443], "weights": [0.5, 1.5], "flags": [true, false], "backoff": ["1s", "1m30s"], "counts": [1000]}`), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*c, expect) {
+		t.Errorf("json: %v", *c)
+	}
+
+	c = new(testSliceConfig)
+	err = ReadYAML(strings.NewReader("ports: [80, \"443\"]\nweights: [0.5, 1.5]\nflags: [yes, false]\nbackoff: [1s, 1m30s]\ncounts: [1000]\n"), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*c, expect) {
+		t.Errorf("yaml: %v", *c)
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := Write(b, &expect); err != nil {
+		t.Fatal(err)
+	}
+	c = new(testSliceConfig)
+	if err := Read(b, c); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*c, expect) {
+		t.Errorf("write: %v", *c)
+	}
+
+	var defaults []string
+	for _, s := range Settings(&expect) {
+		defaults = append(defaults, s.Path+" "+s.Type.String()+" "+s.Default)
+	}
+	if !reflect.DeepEqual(defaults, []string{
+		"ports []uint16 [80 443]",
+		"weights []float64 [0.5 1.5]",
+		"flags []bool [true false]",
+		"backoff []time.Duration [1s 1m30s]",
+		"counts []int [1000]",
+	}) {
+		t.Error(defaults)
+	}
+}
@@ -11,13 +11,16 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
-// Setting documents a settable configuration path.
+// Setting documents a settable configuration path.  The description is
+// specified using the "help" tag of the struct field.
 type Setting struct {
-	Path    string
-	Type    reflect.Type
-	Default string
+	Path        string
+	Type        reflect.Type
+	Default     string
+	Description string
 }
 
 func (s Setting) String() string {
@@ -70,7 +73,7 @@ func enumerateMap(list []Setting, prefix string, node reflect.Value) []Setting {
 			value = value.Elem()
 		}
 
-		list = enumerateMember(list, path, value)
+		list = enumerateMember(list, path, value, "")
 	}
 
 	return list
@@ -89,30 +92,48 @@ func enumerateStruct(list []Setting, prefix string, node reflect.Value) []Settin
 
 		field := node.Type().Field(i)
 
+		key, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+
 		path := prefix
-		if !field.Anonymous {
+		if key != "" {
 			if len(path) > 0 {
 				path += "."
 			}
-			path += strings.ToLower(field.Name)
+			path += key
 		}
 
-		if field.Type.Kind() == reflect.Ptr {
+		if field.Type.Kind() == reflect.Ptr && !isCustomType(field.Type) {
 			list = enumerateContainer(list, path, value)
 		} else {
-			list = enumerateMember(list, path, value)
+			list = enumerateMember(list, path, value, field.Tag.Get("help"))
 		}
 	}
 
 	return list
 }
 
-func enumerateMember(list []Setting, path string, value reflect.Value) []Setting {
+func enumerateMember(list []Setting, path string, value reflect.Value, description string) []Setting {
+	if isCustomType(value.Type()) {
+		s := Setting{
+			Path:        path,
+			Type:        value.Type(),
+			Description: description,
+		}
+		if !value.IsZero() {
+			s.Default = displayCustom(value)
+		}
+		return append(list, s)
+	}
+
 	switch value.Kind() {
 	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.String:
 		s := Setting{
-			Path: path,
-			Type: value.Type(),
+			Path:        path,
+			Type:        value.Type(),
+			Description: description,
 		}
 		if x := value.Interface(); x != reflect.Zero(value.Type()).Interface() {
 			s.Default = fmt.Sprint(x)
@@ -120,18 +141,19 @@ func enumerateMember(list []Setting, path string, value reflect.Value) []Setting
 		list = append(list, s)
 
 	case reflect.Slice:
-		switch value.Type().Elem().Kind() {
-		case reflect.String:
+		switch elem := value.Type().Elem(); {
+		case isScalarType(elem):
 			s := Setting{
-				Path: path,
-				Type: value.Type(),
+				Path:        path,
+				Type:        value.Type(),
+				Description: description,
 			}
-			if repr := fmt.Sprintf("%q", value.Interface()); len(repr) > 2 {
-				s.Default = repr
+			if value.Len() > 0 {
+				s.Default = formatValue(value.Interface())
 			}
 			list = append(list, s)
 
-		case reflect.Struct:
+		case elem.Kind() == reflect.Struct:
 			list = enumerateSlice(list, path, value)
 		}
 
@@ -146,17 +168,44 @@ func enumerateMember(list []Setting, path string, value reflect.Value) []Setting
 }
 
 // PrintSettings of the given configuration.  Writer defaults to the default
-// flag set's output.
+// flag set's output.  Descriptions are aligned in a column.
 func PrintSettings(w io.Writer, config interface{}) {
 	if w == nil {
 		w = flag.CommandLine.Output()
 	}
 
-	for _, s := range Settings(config) {
+	settings := Settings(config)
+	columns := make([]string, len(settings))
+	notes := make([]string, len(settings))
+
+	for i, s := range settings {
 		if s.Default == "" {
-			fmt.Fprintf(w, "  %s %s\n", s.Path, s.Type)
+			columns[i] = fmt.Sprintf("%s %s", s.Path, s.Type)
+		} else {
+			columns[i] = fmt.Sprintf("%s %s (%s)", s.Path, s.Type, s.Default)
+		}
+		notes[i] = s.Description
+	}
+
+	printColumns(w, columns, notes)
+}
+
+// printColumns writes indented lines, with non-empty notes aligned after the
+// columns.
+func printColumns(w io.Writer, columns, notes []string) {
+	width := 0
+	for _, s := range columns {
+		if n := utf8.RuneCountInString(s); n > width {
+			width = n
+		}
+	}
+
+	for i, s := range columns {
+		if notes[i] == "" {
+			fmt.Fprintf(w, "  %s\n", s)
 		} else {
-			fmt.Fprintf(w, "  %s %s (%s)\n", s.Path, s.Type, s.Default)
+			padding := strings.Repeat(" ", width-utf8.RuneCountInString(s))
+			fmt.Fprintf(w, "  %s%s  %s\n", s, padding, notes[i])
 		}
 	}
 }
@@ -179,6 +228,29 @@ func FlagUsage(flags *flag.FlagSet, config interface{}) func() {
 	}
 }
 
+// formatValue of a setting for display.
+func formatValue(x interface{}) string {
+	if slice, ok := x.([]string); ok {
+		if len(slice) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%q", slice)
+	}
+	if v := reflect.ValueOf(x); v.IsValid() {
+		if isCustomType(v.Type()) {
+			return displayCustom(v)
+		}
+		if v.Kind() == reflect.Slice && isCustomType(v.Type().Elem()) {
+			items := make([]string, v.Len())
+			for i := range items {
+				items[i] = displayCustom(v.Index(i))
+			}
+			return "[" + strings.Join(items, " ") + "]"
+		}
+	}
+	return fmt.Sprint(x)
+}
+
 func reflectMapKeyStrings(value reflect.Value) []string {
 	var strs []string
 	for _, x := range value.MapKeys() {
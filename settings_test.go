@@ -15,30 +15,31 @@ func TestSettings(t *testing.T) {
 	c := newTestConfig()
 
 	if ss := Settings(c); !reflect.DeepEqual(ss, []Setting{
-		{"foo.key1", reflect.TypeOf(false), ""},
-		{"foo.key2", reflect.TypeOf(0), ""},
-		{"foo.key2b", reflect.TypeOf(int8(0)), ""},
-		{"foo.key3a", reflect.TypeOf(int16(0)), ""},
-		{"foo.key3", reflect.TypeOf(int32(0)), ""},
-		{"foo.key4", reflect.TypeOf(int64(0)), ""},
-		{"foo.key5", reflect.TypeOf(uint(0)), ""},
-		{"foo.key5b", reflect.TypeOf(uint8(0)), ""},
-		{"foo.key6a", reflect.TypeOf(uint16(0)), ""},
-		{"foo.key6", reflect.TypeOf(uint32(0)), ""},
-		{"foo.key7", reflect.TypeOf(uint64(0)), ""},
-		{"foo.key8", reflect.TypeOf(float32(0)), ""},
-		{"foo.key9", reflect.TypeOf(0.0), ""},
-		{"foo.key10", reflect.TypeOf(""), ""},
-		{"foo.key11", reflect.TypeOf([]string{}), ""},
-		{"bar", reflect.TypeOf(0), "67890"},
-		{"baz.quux.key_a", reflect.TypeOf(""), ""},
-		{"baz.quux.key_b", reflect.TypeOf(false), ""},
-		{"baz.interval", reflect.TypeOf(time.Duration(0)), ""},
-		{"baz.embedded", reflect.TypeOf(false), ""},
-		{"baz.embed1.embedded", reflect.TypeOf(false), ""},
-		{"baz.embed2.embedded", reflect.TypeOf(false), ""},
-		{"ext.a.average", reflect.TypeOf(0), ""},
-		{"ext.b.beverage", reflect.TypeOf(0), ""},
+		{"foo.key1", reflect.TypeOf(false), "", ""},
+		{"foo.key2", reflect.TypeOf(0), "", ""},
+		{"foo.key2b", reflect.TypeOf(int8(0)), "", ""},
+		{"foo.key3a", reflect.TypeOf(int16(0)), "", ""},
+		{"foo.key3", reflect.TypeOf(int32(0)), "", ""},
+		{"foo.key4", reflect.TypeOf(int64(0)), "", ""},
+		{"foo.key5", reflect.TypeOf(uint(0)), "", ""},
+		{"foo.key5b", reflect.TypeOf(uint8(0)), "", ""},
+		{"foo.key6a", reflect.TypeOf(uint16(0)), "", ""},
+		{"foo.key6", reflect.TypeOf(uint32(0)), "", ""},
+		{"foo.key7", reflect.TypeOf(uint64(0)), "", ""},
+		{"foo.key8", reflect.TypeOf(float32(0)), "", ""},
+		{"foo.key9", reflect.TypeOf(0.0), "", ""},
+		{"foo.key10", reflect.TypeOf(""), "", ""},
+		{"foo.key11", reflect.TypeOf([]string{}), "", ""},
+		{"bar", reflect.TypeOf(0), "67890", ""},
+		{"baz.quux.key_a", reflect.TypeOf(""), "", ""},
+		{"baz.quux.key_b", reflect.TypeOf(false), "", ""},
+		{"baz.interval", reflect.TypeOf(time.Duration(0)), "", ""},
+		{"baz.sample_rate", reflect.TypeOf(0), "", "Audio sample rate"},
+		{"baz.embedded", reflect.TypeOf(false), "", ""},
+		{"baz.embed1.embedded", reflect.TypeOf(false), "", ""},
+		{"baz.embed2.embedded", reflect.TypeOf(false), "", ""},
+		{"ext.a.average", reflect.TypeOf(0), "", ""},
+		{"ext.b.beverage", reflect.TypeOf(0), "", ""},
 	}) {
 		t.Errorf("%#v", ss)
 	}
@@ -47,3 +48,24 @@ func TestSettings(t *testing.T) {
 	PrintSettings(b, c)
 	t.Logf("\n%s", b)
 }
+
+func TestPrintSettings(t *testing.T) {
+	var c struct {
+		Comment string `help:"Free text"`
+		Audio   struct {
+			Enabled    bool
+			SampleRate int `help:"Sample rate in Hz"`
+		}
+	}
+	c.Audio.SampleRate = 44100
+
+	b := new(bytes.Buffer)
+	PrintSettings(b, &c)
+
+	if s := b.String(); s != `  comment string                Free text
+  audio.enabled bool
+  audio.samplerate int (44100)  Sample rate in Hz
+` {
+		t.Error(s)
+	}
+}
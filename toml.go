@@ -6,24 +6,83 @@ package confi
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/naoina/toml"
 	"github.com/naoina/toml/ast"
 )
 
-// Read TOML into the configuration.
+// Read TOML into the configuration.  Errors concerning the document are of
+// type *Error.  The configuration is validated after reading; see Validate.
 func Read(r io.Reader, config interface{}) error {
-	return read(r, config, false)
+	if err := read(r, config, false); err != nil {
+		return err
+	}
+	return Validate(config)
+}
+
+// ReadAll is like Read, but it doesn't stop at the first error.  The returned
+// error is an ErrorList.  The configuration is validated if reading succeeds.
+func ReadAll(r io.Reader, config interface{}) error {
+	var errs ErrorList
+	readTOML(r, "", config, false, &errs)
+	if len(errs) == 0 {
+		if err := Validate(config); err != nil {
+			errs.add(Error{}, err)
+		}
+	}
+	return errs.err()
+}
+
+func read(r io.Reader, config interface{}, ignoreUnknown bool) error {
+	return readTOML(r, "", config, ignoreUnknown, nil)
+}
+
+// readTOML stops at the first error if errs is nil.  Otherwise errors are
+// appended to it, and nil is returned.  Errors are of type *Error.
+func readTOML(r io.Reader, filename string, config interface{}, ignoreUnknown bool, errs *ErrorList) error {
+	var including []string
+	if filename != "" {
+		including = []string{absPath(filename)}
+	}
+
+	return readTOMLIncluding(r, filename, config, ignoreUnknown, errs, including)
 }
 
-func read(r io.Reader, config interface{}, ignoreUnknown bool) (err error) {
+// readTOMLIncluding is like readTOML.  including is the chain of absolute
+// filenames which are being read, ending with the current file (if any).
+func readTOMLIncluding(r io.Reader, filename string, config interface{}, ignoreUnknown bool, errs *ErrorList, including []string) (err error) {
+	allErrs := errs
+
+	if allErrs != nil {
+		var fileErrs ErrorList
+
+		defer func() {
+			if err != nil {
+				fileErrs.add(Error{Filename: filename}, err)
+				err = nil
+			}
+			sort.SliceStable(fileErrs, func(i, j int) bool {
+				return fileErrs[i].Line < fileErrs[j].Line
+			})
+			*allErrs = append(*allErrs, fileErrs...)
+		}()
+
+		errs = &fileErrs
+	}
+
 	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return
@@ -31,6 +90,11 @@ func read(r io.Reader, config interface{}, ignoreUnknown bool) (err error) {
 
 	table, err := toml.Parse(data)
 	if err != nil {
+		if e, ok := err.(*toml.LineError); ok {
+			err = &Error{Filename: filename, Line: e.Line, Err: e.Err}
+		} else {
+			err = &Error{Filename: filename, Err: err}
+		}
 		return
 	}
 
@@ -38,29 +102,204 @@ func read(r io.Reader, config interface{}, ignoreUnknown bool) (err error) {
 		err = asError(recover())
 	}()
 
-	setFields(config, "", table.Fields, ignoreUnknown)
+	tr := tomlReader{config, filename, []rune(string(data)), ignoreUnknown, errs}
+
+	if kv, ok := table.Fields[includeKey].(*ast.KeyValue); ok && !hasKey(config, includeKey) {
+		delete(table.Fields, includeKey)
+
+		var names []string
+		tr.try(kv.Line, kv.Value.Pos(), includeKey, func() {
+			names = includeFilenames(filename, kv.Value, lookupIncludeObserver(config))
+		})
+
+		for _, name := range names {
+			tr.include(kv, name, allErrs, including)
+		}
+	}
+
+	tr.setFields("", table.Fields)
 	return
 }
 
-// ReadFile containing TOML into the configuration.
+// includeKey is reserved for the include directive at the top level of TOML
+// files, unless the configuration has a setting with the same name.
+const includeKey = "include"
+
+var includeObservers sync.Map // Configuration object pointer -> func(string).
+
+// observeIncludes calls f with the filename or glob pattern of each include
+// directive which is read into config, until the returned function is called.
+func observeIncludes(config interface{}, f func(pattern string)) (restore func()) {
+	if !trackable(config) {
+		return func() {}
+	}
+
+	includeObservers.Store(config, f)
+	return func() {
+		includeObservers.Delete(config)
+	}
+}
+
+func lookupIncludeObserver(config interface{}) func(string) {
+	if !trackable(config) {
+		return nil
+	}
+	if x, found := includeObservers.Load(config); found {
+		return x.(func(string))
+	}
+	return nil
+}
+
+// includeFilenames resolves the filenames and glob patterns of an include
+// directive relative to the directory of the including file.  The resolved
+// patterns are passed to observe if it's non-nil.
+func includeFilenames(filename string, value ast.Value, observe func(string)) (names []string) {
+	var patterns []string
+
+	switch x := value.(type) {
+	case *ast.String:
+		patterns = []string{x.Value}
+
+	case *ast.Array:
+		for _, v := range x.Value {
+			s, ok := v.(*ast.String)
+			if !ok {
+				panic(errors.New("include directive must be a string or an array of strings"))
+			}
+			patterns = append(patterns, s.Value)
+		}
+
+	default:
+		panic(errors.New("include directive must be a string or an array of strings"))
+	}
+
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(filename), pattern)
+		}
+		if observe != nil {
+			observe(pattern)
+		}
+
+		if !strings.ContainsAny(pattern, "*?[") {
+			names = append(names, pattern)
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			panic(err)
+		}
+		names = append(names, matches...)
+	}
+
+	return
+}
+
+// include reads another file.  Errors concerning its contents are appended to
+// errs if it's non-nil.
+func (tr *tomlReader) include(kv *ast.KeyValue, filename string, errs *ErrorList, including []string) {
+	abs := absPath(filename)
+
+	var f *os.File
+
+	tr.try(kv.Line, kv.Value.Pos(), includeKey, func() {
+		for i, name := range including {
+			if name == abs {
+				chain := append(append([]string(nil), including[i:]...), abs)
+				panic(fmt.Errorf("include cycle: %s", strings.Join(chain, " -> ")))
+			}
+		}
+
+		var err error
+		if f, err = os.Open(filename); err != nil {
+			panic(err)
+		}
+	})
+	if f == nil {
+		return
+	}
+	defer f.Close()
+
+	chain := append(including[:len(including):len(including)], abs)
+
+	if err := readTOMLIncluding(f, filename, tr.config, tr.ignoreUnknown, errs, chain); err != nil {
+		panic(err)
+	}
+}
+
+func absPath(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		return abs
+	}
+	return filename
+}
+
+func hasKey(config interface{}, path string) (found bool) {
+	defer func() {
+		if x := recover(); x != nil && !isUnknownKey(x) {
+			panic(x)
+		}
+	}()
+
+	lookupField(config, path)
+	return true
+}
+
+// ReadFile containing TOML into the configuration.  Errors concerning the file
+// contents are of type *Error.  The configuration is validated after reading;
+// see Validate.
 func ReadFile(filename string, config interface{}) error {
-	return readFile(filename, config, false)
+	if err := readFile(filename, config, false); err != nil {
+		return err
+	}
+	return Validate(config)
+}
+
+// ReadFileAll is like ReadFile, but it doesn't stop at the first error.  The
+// returned error is an ErrorList.  The configuration is validated if reading
+// succeeds.
+func ReadFileAll(filename string, config interface{}) error {
+	var errs ErrorList
+	readTOMLFile(filename, config, false, &errs)
+	if len(errs) == 0 {
+		if err := Validate(config); err != nil {
+			errs.add(Error{}, err)
+		}
+	}
+	return errs.err()
 }
 
-func readFile(filename string, config interface{}, ignoreUnknown bool) (err error) {
+func readFile(filename string, config interface{}, ignoreUnknown bool) error {
+	return readTOMLFile(filename, config, ignoreUnknown, nil)
+}
+
+func readTOMLFile(filename string, config interface{}, ignoreUnknown bool, errs *ErrorList) (err error) {
 	f, err := os.Open(filename)
 	if err != nil {
+		if errs != nil {
+			errs.add(Error{}, err)
+			err = nil
+		}
 		return
 	}
 	defer f.Close()
 
-	return read(f, config, ignoreUnknown)
+	return readTOML(f, filename, config, ignoreUnknown, errs)
 }
 
 // ReadFileIfExists is a lenient alternative to the ReadFile method..  No error
-// is returned if the file doesn't exist.
+// is returned if the file doesn't exist.  The configuration is validated if the
+// file was read.
 func ReadFileIfExists(filename string, config interface{}) error {
-	return readFileIfExists(filename, config, false)
+	err := readFile(filename, config, false)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return err
+	}
+	return Validate(config)
 }
 
 func readFileIfExists(filename string, config interface{}, ignoreUnknown bool) (err error) {
@@ -71,7 +310,15 @@ func readFileIfExists(filename string, config interface{}, ignoreUnknown bool) (
 	return
 }
 
-func setFields(config interface{}, path string, fields map[string]interface{}, ignoreUnknown bool) {
+type tomlReader struct {
+	config        interface{}
+	filename      string
+	data          []rune
+	ignoreUnknown bool
+	errs          *ErrorList // Stop at first error if nil.
+}
+
+func (tr *tomlReader) setFields(path string, fields map[string]interface{}) {
 	for k, v := range fields {
 		p := k
 		if path != "" {
@@ -80,75 +327,266 @@ func setFields(config interface{}, path string, fields map[string]interface{}, i
 
 		switch x := v.(type) {
 		case *ast.KeyValue:
-			var s string
-
-			switch y := x.Value.(type) {
-			case *ast.Array:
-				s = x.Value.Source()
-			case *ast.Boolean:
-				s = y.Value
-			case *ast.Float:
-				s = y.Value
-			case *ast.Integer:
-				s = y.Value
-			case *ast.String:
-				s = y.Value
-			default:
-				panic(fmt.Errorf("%s: type not supported: %#v", p, x.Value))
-			}
-
-			if ignoreUnknown {
-				func() {
-					defer func() {
-						if x := recover(); x != nil {
-							if _, ok := x.(unknownKeyError); !ok {
-								panic(x)
-							}
-						}
-					}()
-					MustSetFromString(config, p, s)
-				}()
-			} else {
-				MustSetFromString(config, p, s)
-			}
+			tr.try(x.Line, x.Value.Pos(), p, func() {
+				var s string
+
+				switch y := x.Value.(type) {
+				case *ast.Array:
+					s = tomlArray(y)
+				case *ast.Boolean:
+					s = y.Value
+				case *ast.Float:
+					s = y.Value
+				case *ast.Integer:
+					s = y.Value
+				case *ast.String:
+					s = y.Value
+				default:
+					panic(fmt.Errorf("type not supported: %#v", x.Value))
+				}
+
+				setFromString(tr.config, p, s, tr.ignoreUnknown)
+			})
 
 		case *ast.Table:
-			setFields(config, p, x.Fields, ignoreUnknown)
+			tr.setFields(p, x.Fields)
 
 		case []*ast.Table:
-			appendTables(config, p, x, ignoreUnknown)
+			tr.appendTables(p, x)
 
 		default:
-			panic(fmt.Errorf("%s: unknown value type: %#v", p, v))
+			panic(&Error{Filename: tr.filename, Path: p, Err: fmt.Errorf("unknown value type: %#v", v)})
 		}
 	}
 }
 
-func appendTables(config interface{}, path string, tables []*ast.Table, ignoreUnknown bool) {
-	node := lookup(config, path)
-	n := node.Len()
+func (tr *tomlReader) appendTables(path string, tables []*ast.Table) {
+	var (
+		n  int
+		ok bool
+	)
+
+	tr.try(tables[0].Line, tables[0].Pos(), path, func() {
+		n = lookup(tr.config, path).Len()
+		ok = true
+	})
+	if !ok {
+		return
+	}
+
 	for i, x := range tables {
-		setFields(config, fmt.Sprintf("%s.%d", path, n+i), x.Fields, ignoreUnknown)
+		tr.setFields(fmt.Sprintf("%s.%d", path, n+i), x.Fields)
 	}
 }
 
-// Write the configuration as TOML.
-func Write(w io.Writer, config interface{}) error {
-	return toml.NewEncoder(w).Encode(sanitizeContainer(make(map[string]interface{}), reflect.ValueOf(config).Elem()))
+// try calls f.  A panic is converted to an Error with location information.
+// If errors are being collected, it is recorded instead of propagated.
+func (tr *tomlReader) try(line, offset int, path string, f func()) {
+	defer withSource(tr.config, Source{Kind: SourceFile, Filename: tr.filename, Line: line})()
+
+	defer func() {
+		if x := recover(); x != nil {
+			pos := Error{
+				Filename: tr.filename,
+				Line:     line,
+				Column:   tr.column(offset),
+				Path:     path,
+			}
+
+			if tr.errs == nil {
+				panic(pos.wrap(asError(x)))
+			}
+			tr.errs.add(pos, asError(x))
+		}
+	}()
+
+	f()
 }
 
-// WriteFile containing the configuration as TOML.
-func WriteFile(filename string, config interface{}) (err error) {
+// tomlArray converts an array of scalars to JSON array representation.
+func tomlArray(array *ast.Array) string {
+	items := []string{}
+
+	for _, v := range array.Value {
+		switch y := v.(type) {
+		case *ast.Boolean:
+			items = append(items, y.Value)
+		case *ast.Float:
+			items = append(items, strings.Replace(y.Value, "_", "", -1))
+		case *ast.Integer:
+			items = append(items, strings.Replace(y.Value, "_", "", -1))
+		case *ast.String:
+			items = append(items, y.Value)
+		default:
+			panic(fmt.Errorf("array item type not supported: %#v", v))
+		}
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// column number (starting at 1) of a rune offset.
+func (tr *tomlReader) column(offset int) int {
+	if offset < 0 || offset > len(tr.data) {
+		return 0
+	}
+
+	column := 1
+	for i := offset - 1; i >= 0 && tr.data[i] != '\n'; i-- {
+		column++
+	}
+	return column
+}
+
+func setFromString(config interface{}, path, repr string, ignoreUnknown bool) {
+	if ignoreUnknown {
+		defer func() {
+			if x := recover(); x != nil && !isUnknownKey(x) {
+				panic(x)
+			}
+		}()
+	}
+
+	MustSetFromString(config, path, repr)
+}
+
+// Write the configuration as TOML.  Setting descriptions are written as
+// comments.  An error is returned if a value can't be marshaled.
+func Write(w io.Writer, config interface{}) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = asError(x)
+		}
+	}()
+
 	b := bytes.NewBuffer(nil)
 
 	err = toml.NewEncoder(b).Encode(sanitizeContainer(make(map[string]interface{}), reflect.ValueOf(config).Elem()))
 	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(commentTOML(b.Bytes(), config))
+	return err
+}
+
+// WriteFile containing the configuration as TOML.
+func WriteFile(filename string, config interface{}) (err error) {
+	b := bytes.NewBuffer(nil)
+
+	if err = Write(b, config); err != nil {
 		return
 	}
 
 	return ioutil.WriteFile(filename, b.Bytes(), 0666)
 }
 
+// commentTOML inserts setting descriptions before key/value lines.  Array
+// table headers are mapped onto the "#" paths of Settings.
+func commentTOML(data []byte, config interface{}) []byte {
+	descriptions := make(map[string]string)
+	for _, s := range Settings(config) {
+		if s.Description != "" {
+			descriptions[s.Path] = s.Description
+		}
+	}
+	if len(descriptions) == 0 {
+		return data
+	}
+
+	b := bytes.NewBuffer(nil)
+	arrays := make(map[string]bool)
+	table := ""
+
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "[") {
+			array := strings.HasPrefix(trimmed, "[[")
+			keys := splitTOMLKey(strings.Trim(trimmed, "[]"))
+
+			table = ""
+			for i, key := range keys {
+				table = joinPath(table, key)
+				if i == len(keys)-1 && array {
+					arrays[table] = true
+				}
+				if arrays[table] {
+					table = joinPath(table, "#")
+				}
+			}
+		} else if i := strings.Index(trimmed, " = "); i > 0 && !strings.HasPrefix(trimmed, "#") {
+			path := table
+			for _, key := range splitTOMLKey(trimmed[:i]) {
+				path = joinPath(path, key)
+			}
+			if desc, found := descriptions[path]; found {
+				indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+				for _, s := range strings.Split(desc, "\n") {
+					fmt.Fprintf(b, "%s# %s\n", indent, s)
+				}
+			}
+		}
+
+		b.WriteString(line)
+	}
+
+	return b.Bytes()
+}
+
+// splitTOMLKey into unquoted components.  Components containing dots are
+// quoted like in paths.
+func splitTOMLKey(s string) (keys []string) {
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var key string
+
+		switch s[0] {
+		case '"':
+			i := 1
+			for i < len(s) && s[i] != '"' {
+				if s[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(s) {
+				i = len(s) - 1
+			}
+			key, _ = strconv.Unquote(s[:i+1])
+			s = s[i+1:]
+
+		case '\'':
+			i := strings.IndexByte(s[1:], '\'') + 1
+			if i == 0 {
+				i = len(s) - 1
+			}
+			key = s[1:i]
+			s = s[i+1:]
+
+		default:
+			i := strings.IndexByte(s, '.')
+			if i < 0 {
+				i = len(s)
+			}
+			key = strings.TrimSpace(s[:i])
+			s = s[i:]
+		}
+
+		if strings.Contains(key, ".") {
+			key = fmt.Sprintf("%q", key)
+		}
+		keys = append(keys, key)
+
+		s = strings.TrimPrefix(strings.TrimSpace(s), ".")
+	}
+
+	return
+}
+
 func sanitizeContainer(sane map[string]interface{}, node reflect.Value) map[string]interface{} {
 	switch node.Kind() {
 	case reflect.Map:
@@ -185,10 +623,13 @@ func sanitizeStruct(sane map[string]interface{}, node reflect.Value) map[string]
 			continue
 		}
 
-		field := node.Type().Field(i)
+		key, ok := fieldKey(node.Type().Field(i))
+		if !ok {
+			continue
+		}
 
-		if x := sanitizeValue(sane, value, field.Anonymous); x != nil {
-			sane[strings.ToLower(field.Name)] = x
+		if x := sanitizeValue(sane, value, key == ""); x != nil {
+			sane[key] = x
 		}
 	}
 
@@ -196,10 +637,22 @@ func sanitizeStruct(sane map[string]interface{}, node reflect.Value) map[string]
 }
 
 func sanitizeValue(sane map[string]interface{}, value reflect.Value, anonymous bool) (x interface{}) {
+	if isCustomType(value.Type()) {
+		if value.Kind() != reflect.Ptr || !value.IsNil() {
+			x = formatCustom(value)
+		}
+		return
+	}
+
 	switch value.Kind() {
-	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.String:
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
 		x = value.Interface()
 
+	case reflect.String:
+		if !isSecret(value) {
+			x = value.Interface()
+		}
+
 	case reflect.Int64:
 		x = value.Interface()
 		if d, ok := x.(time.Duration); ok {
@@ -207,9 +660,19 @@ func sanitizeValue(sane map[string]interface{}, value reflect.Value, anonymous b
 		}
 
 	case reflect.Slice:
-		switch value.Type().Elem().Kind() {
-		case reflect.String:
+		switch kind := value.Type().Elem().Kind(); {
+		case value.Type().Elem() == secretType:
+			// Omitted.
+
+		case kind == reflect.String && !isCustomType(value.Type().Elem()):
 			x = value.Interface()
+
+		case isScalarType(value.Type().Elem()):
+			items := make([]interface{}, value.Len())
+			for i := range items {
+				items[i] = sanitizeValue(nil, value.Index(i), false)
+			}
+			x = items
 		}
 
 	case reflect.Map:
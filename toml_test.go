@@ -6,6 +6,9 @@ package confi
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
 	"strings"
 	"testing"
 )
@@ -63,6 +66,60 @@ func TestReadFileIfExists(t *testing.T) {
 	}
 }
 
+func TestReadFileInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"conf.d", "cycle"} {
+		if err := os.Mkdir(path.Join(dir, name), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, data := range map[string]string{
+		"main.toml":     "include = [\"common.toml\", \"conf.d/*.toml\"]\nbar = 3\n",
+		"common.toml":   "bar = 1\n[foo]\nkey10 = \"common\"\nkey2 = 1\n",
+		"conf.d/a.toml": "include = \"../base.toml\"\n[foo]\nkey2 = 2\n",
+		"base.toml":     "[baz]\nsample_rate = 44100\n",
+		"cycle.toml":    "include = [\"cycle/b.toml\"]\n",
+		"cycle/b.toml":  "include = [\"../cycle.toml\"]\n",
+		"missing.toml":  "include = [\"nonexistent.toml\"]\n",
+	} {
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := newTestConfig()
+
+	if err := ReadFile(path.Join(dir, "main.toml"), c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Bar != 3 {
+		t.Error(c.Bar)
+	}
+	if c.Foo.Key10 != "common" || c.Foo.Key2 != 2 {
+		t.Error(c.Foo.Key10, c.Foo.Key2)
+	}
+	if c.Baz.SampleRate != 44100 {
+		t.Error(c.Baz.SampleRate)
+	}
+
+	err = ReadFile(path.Join(dir, "cycle.toml"), newTestConfig())
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Error(err)
+	}
+
+	err = ReadFileAll(path.Join(dir, "missing.toml"), newTestConfig())
+	if e, ok := err.(ErrorList); !ok || len(e) != 1 || e[0].Line != 1 || e[0].Path != "include" {
+		t.Error(err)
+	}
+}
+
 func TestWrite(t *testing.T) {
 	c := newTestConfig()
 
@@ -80,3 +137,60 @@ func TestWrite(t *testing.T) {
 		t.Error(s)
 	}
 }
+
+type testCommentItem struct {
+	Name string `help:"Item name"`
+	Subs []struct {
+		Value int `help:"Sub value"`
+	}
+}
+
+type testCommentConfig struct {
+	Items []testCommentItem
+	Ext   map[string]interface{}
+}
+
+type testCommentExt struct {
+	Average int `help:"Average value"`
+}
+
+func TestWriteComments(t *testing.T) {
+	c := &testCommentConfig{
+		Ext: map[string]interface{}{
+			"a.b": &testCommentExt{1},
+		},
+	}
+
+	b := new(bytes.Buffer)
+	if err := Write(b, c); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); !strings.Contains(s, "[ext.\"a.b\"]\n# Average value\naverage = 1\n") {
+		t.Error(s)
+	}
+
+	data := commentTOML([]byte(`[[items]]
+name = "a"
+
+[[items.subs]]
+'value' = 5
+
+[[items]]
+  "name" = "b"
+`), c)
+
+	if s := string(data); s != `[[items]]
+# Item name
+name = "a"
+
+[[items.subs]]
+# Sub value
+'value' = 5
+
+[[items]]
+  # Item name
+  "name" = "b"
+` {
+		t.Error(s)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SourceKind identifies the type of a configuration value's source.
+type SourceKind int
+
+// Source kinds.
+const (
+	SourceDefault    SourceKind = iota // Value was present when tracking started.
+	SourceProgram                      // Set via a function call.
+	SourceFile                         // Read from a file.
+	SourceAssignment                   // Set via an assignment expression.
+	SourceEnv                          // Read from an environment variable.
+	SourceFlag                         // Set via a per-setting flag.
+)
+
+// Source of a configuration value.  Filename and Line are set for files (if
+// known), Expr for assignments, and Name for environment variables and flags.
+type Source struct {
+	Kind     SourceKind
+	Filename string
+	Line     int
+	Expr     string
+	Name     string
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceDefault:
+		return "default"
+
+	case SourceFile:
+		switch {
+		case s.Filename == "" && s.Line == 0:
+			return "file"
+		case s.Filename == "":
+			return fmt.Sprintf("line %d", s.Line)
+		case s.Line == 0:
+			return s.Filename
+		default:
+			return fmt.Sprintf("%s:%d", s.Filename, s.Line)
+		}
+
+	case SourceAssignment:
+		return fmt.Sprintf("assignment %q", s.Expr)
+
+	case SourceEnv:
+		return "environment variable " + s.Name
+
+	case SourceFlag:
+		return "flag -" + s.Name
+
+	default:
+		return "program"
+	}
+}
+
+// Tracker records the sources of configuration values.
+type Tracker struct {
+	config  interface{}
+	mu      sync.Mutex
+	sources map[string]Source
+	current *Source
+}
+
+var trackers sync.Map // Configuration object pointer -> *Tracker.
+
+// Track starts recording the sources of configuration values.  Values set
+// via this package are attributed to the sources which set them; other values
+// are attributed to defaults.  Only one tracker may be active per
+// configuration object.  Nothing is recorded unless config is a pointer.
+func Track(config interface{}) *Tracker {
+	t := &Tracker{
+		config:  config,
+		sources: make(map[string]Source),
+	}
+	if trackable(config) {
+		trackers.Store(config, t)
+	}
+	return t
+}
+
+// Stop tracking.  The recorded sources can still be queried.
+func (t *Tracker) Stop() {
+	if !trackable(t.config) {
+		return
+	}
+	if x, found := trackers.Load(t.config); found && x == t {
+		trackers.Delete(t.config)
+	}
+}
+
+// Source of the value at the given path.
+func (t *Tracker) Source(path string) Source {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.sources[path] // Zero value is SourceDefault.
+}
+
+// Sources of all non-default values.
+func (t *Tracker) Sources() map[string]Source {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := make(map[string]Source, len(t.sources))
+	for path, s := range t.sources {
+		m[path] = s
+	}
+	return m
+}
+
+// Print the current values of the configuration and their sources.  Writer
+// defaults to the default flag set's output.
+func (t *Tracker) Print(w io.Writer) {
+	if w == nil {
+		w = flag.CommandLine.Output()
+	}
+
+	sources := t.Sources()
+
+	var paths []string
+	for _, s := range Settings(t.config) {
+		if !strings.Contains(s.Path, "#") {
+			paths = append(paths, s.Path)
+			delete(sources, s.Path)
+		}
+	}
+
+	var extra []string // Struct slice elements.
+	for path := range sources {
+		extra = append(extra, path)
+	}
+	sort.Strings(extra)
+	paths = append(paths, extra...)
+
+	columns := make([]string, len(paths))
+	notes := make([]string, len(paths))
+
+	for i, path := range paths {
+		repr := ""
+		if x, err := Get(t.config, path); err == nil {
+			repr = formatValue(x)
+		}
+		columns[i] = fmt.Sprintf("%s = %s", path, repr)
+		notes[i] = t.Source(path).String()
+	}
+
+	printColumns(w, columns, notes)
+}
+
+func (t *Tracker) record(path string, secret bool) {
+	s := Source{Kind: SourceProgram}
+	if t.current != nil {
+		s = *t.current
+	}
+	if secret {
+		s.Expr = redactExpr(s.Expr)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sources[path] = s
+}
+
+func lookupTracker(config interface{}) *Tracker {
+	if !trackable(config) {
+		return nil // Maps can't be used as keys.
+	}
+	if x, found := trackers.Load(config); found {
+		return x.(*Tracker)
+	}
+	return nil
+}
+
+// trackable configuration objects are pointers, which identify them.
+func trackable(config interface{}) bool {
+	return reflect.ValueOf(config).Kind() == reflect.Ptr
+}
+
+// withSource attributes values set by the caller to the source, until the
+// returned function is called.
+func withSource(config interface{}, s Source) (restore func()) {
+	t := lookupTracker(config)
+	if t == nil {
+		return func() {}
+	}
+
+	prev := t.current
+	t.current = &s
+	return func() {
+		t.current = prev
+	}
+}
+
+// recordSource of a value.  The value of an assignment expression is redacted
+// if secret is true.
+func recordSource(config interface{}, path string, secret bool) {
+	if t := lookupTracker(config); t != nil {
+		t.record(path, secret)
+	}
+}
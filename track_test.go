@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tomlFile := path.Join(dir, "a.toml")
+	if err := ioutil.WriteFile(tomlFile, []byte("[foo]\nkey1 = true\nkey2 = 2\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonFile := path.Join(dir, "b.json")
+	if err := ioutil.WriteFile(jsonFile, []byte(`{"foo": {"key3": 3}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("CONFI_TRACK_FOO_KEY4", "4")
+	defer os.Unsetenv("CONFI_TRACK_FOO_KEY4")
+
+	c := newTestConfig()
+	tracker := Track(c)
+	defer tracker.Stop()
+
+	b := NewBuffer()
+	b.FileReader().Set(tomlFile)
+	b.FileReader().Set(jsonFile)
+	b.EnvReader().Set("CONFI_TRACK_")
+	b.Assigner().Set("foo.key5=5")
+	if err := b.Apply(c); err != nil {
+		t.Fatal(err)
+	}
+
+	s := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := RegisterFlags(s, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Parse([]string{"-foo.key6=6"}); err != nil {
+		t.Fatal(err)
+	}
+
+	MustSet(c, "foo.key7", uint64(7))
+
+	for path, expect := range map[string]Source{
+		"foo.key1":  {Kind: SourceFile, Filename: tomlFile, Line: 2},
+		"foo.key2":  {Kind: SourceFile, Filename: tomlFile, Line: 3},
+		"foo.key3":  {Kind: SourceFile, Filename: jsonFile},
+		"foo.key4":  {Kind: SourceEnv, Name: "CONFI_TRACK_FOO_KEY4"},
+		"foo.key5":  {Kind: SourceAssignment, Expr: "foo.key5=5"},
+		"foo.key6":  {Kind: SourceFlag, Name: "foo.key6"},
+		"foo.key7":  {Kind: SourceProgram},
+		"foo.key10": {Kind: SourceDefault},
+		"bar":       {Kind: SourceDefault},
+	} {
+		if s := tracker.Source(path); s != expect {
+			t.Errorf("%s: %#v", path, s)
+		}
+	}
+
+	if n := len(tracker.Sources()); n != 7 {
+		t.Error(n)
+	}
+
+	out := new(bytes.Buffer)
+	tracker.Print(out)
+	t.Logf("\n%s", out)
+
+	for _, line := range []string{
+		"foo.key1 = true",
+		"foo.key2 = 2",
+		"bar = 67890",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Error(line)
+		}
+	}
+
+	tracker.Stop()
+	MustSet(c, "foo.key8", float32(8))
+	if s := tracker.Source("foo.key8"); s.Kind != SourceDefault {
+		t.Error(s)
+	}
+}
+
+func TestTrackerMap(t *testing.T) {
+	c := map[string]interface{}{
+		"a": new(testExtA),
+	}
+
+	tracker := Track(c)
+	defer tracker.Stop()
+
+	if err := Set(c, "a.average", 5); err != nil {
+		t.Fatal(err)
+	}
+	if x := c["a"].(*testExtA).Average; x != 5 {
+		t.Error(x)
+	}
+	if s := tracker.Source("a.average"); s.Kind != SourceDefault {
+		t.Error(s)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Built-in handling of some standard library types:
+//
+//	*url.URL                 parsed with url.Parse
+//	net.IPNet and *net.IPNet CIDR notation, such as "192.0.2.0/24" (the empty
+//	                         string is the zero value)
+//	os.FileMode              octal number, such as "0644"
+//
+// net.IP, and netip.Addr, netip.AddrPort and netip.Prefix (Go 1.18), are
+// supported via their encoding.TextUnmarshaler implementations.
+func init() {
+	RegisterType(reflect.TypeOf((*url.URL)(nil)), parseURL, formatURL)
+	RegisterType(reflect.TypeOf(net.IPNet{}), parseIPNet, formatIPNet)
+	RegisterType(reflect.TypeOf((*net.IPNet)(nil)), parseIPNetPtr, formatIPNet)
+	RegisterType(reflect.TypeOf(os.FileMode(0)), parseFileMode, formatFileMode)
+}
+
+func parseURL(repr string) (interface{}, error) {
+	return url.Parse(repr)
+}
+
+func formatURL(x interface{}) string {
+	return x.(*url.URL).String()
+}
+
+func parseIPNet(repr string) (interface{}, error) {
+	if repr == "" {
+		return net.IPNet{}, nil
+	}
+
+	_, n, err := net.ParseCIDR(repr)
+	if err != nil {
+		return nil, err
+	}
+	return *n, nil
+}
+
+func parseIPNetPtr(repr string) (interface{}, error) {
+	_, n, err := net.ParseCIDR(repr)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func formatIPNet(x interface{}) string {
+	n, ok := x.(net.IPNet)
+	if !ok {
+		n = *x.(*net.IPNet)
+	}
+	if n.IP == nil {
+		return ""
+	}
+	return n.String()
+}
+
+func parseFileMode(repr string) (interface{}, error) {
+	n, err := strconv.ParseUint(repr, 8, 32)
+	if err != nil {
+		return nil, err
+	}
+	return os.FileMode(n), nil
+}
+
+func formatFileMode(x interface{}) string {
+	return fmt.Sprintf("%#o", uint32(x.(os.FileMode)))
+}
+
+// Address is a "host:port" string.  The host may be empty, and the port must
+// be a number.  IPv6 hosts must be enclosed in brackets.
+type Address string
+
+// Host part of the address.
+func (a Address) Host() string {
+	host, _, _ := net.SplitHostPort(string(a))
+	return host
+}
+
+// Port number of the address.
+func (a Address) Port() uint16 {
+	_, port, _ := net.SplitHostPort(string(a))
+	n, _ := strconv.ParseUint(port, 10, 16)
+	return uint16(n)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.  The empty string is
+// accepted as an unset address.
+func (a *Address) UnmarshalText(text []byte) error {
+	s := string(text)
+
+	if s != "" {
+		_, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return err
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("invalid port number in address %q", s)
+		}
+	}
+
+	*a = Address(s)
+	return nil
+}
+
+// ByteSize is a number of bytes.  It can be represented with a decimal (kB,
+// MB, GB, TB, PB, EB) or binary (KiB, MiB, GiB, TiB, PiB, EiB) unit suffix,
+// such as "64MiB" or "1.5GB".  Unit suffixes are case-insensitive, and "B" may
+// be omitted.  The result must be a whole number of bytes.  The empty string
+// is zero.
+type ByteSize uint64
+
+var byteUnits = []struct {
+	suffix string
+	size   uint64
+}{
+	{"EiB", 1 << 60},
+	{"PiB", 1 << 50},
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"EB", 1e18},
+	{"PB", 1e15},
+	{"TB", 1e12},
+	{"GB", 1e9},
+	{"MB", 1e6},
+	{"kB", 1e3},
+}
+
+// String representation with the exact unit which yields the smallest number.
+func (n ByteSize) String() string {
+	number := uint64(n)
+	suffix := ""
+
+	if n != 0 {
+		for _, unit := range byteUnits {
+			if uint64(n)%unit.size == 0 && uint64(n)/unit.size < number {
+				number = uint64(n) / unit.size
+				suffix = unit.suffix
+			}
+		}
+	}
+
+	return strconv.FormatUint(number, 10) + suffix
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (n ByteSize) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (n *ByteSize) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		*n = 0
+		return nil
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	number := s[:i]
+	suffix := strings.ToLower(strings.TrimSpace(s[i:]))
+
+	size := uint64(0)
+
+	switch strings.TrimSuffix(suffix, "b") {
+	case "":
+		size = 1
+	default:
+		for _, unit := range byteUnits {
+			if name := strings.ToLower(unit.suffix); suffix == name || suffix+"b" == name {
+				size = unit.size
+				break
+			}
+		}
+	}
+	if size == 0 {
+		return fmt.Errorf("invalid byte size unit: %q", s)
+	}
+
+	if !strings.Contains(number, ".") {
+		x, err := strconv.ParseUint(number, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid byte size: %q", s)
+		}
+		if x > math.MaxUint64/size {
+			return fmt.Errorf("byte size out of range: %q", s)
+		}
+		*n = ByteSize(x * size)
+		return nil
+	}
+
+	r, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return fmt.Errorf("invalid byte size: %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetUint64(size))
+	if !r.IsInt() {
+		return fmt.Errorf("byte size is not a whole number of bytes: %q", s)
+	}
+	if !r.Num().IsUint64() {
+		return fmt.Errorf("byte size out of range: %q", s)
+	}
+	*n = ByteSize(r.Num().Uint64())
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package confi
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+type testNetipConfig struct {
+	Addr   netip.Addr
+	Prefix netip.Prefix
+	Allow  []netip.Prefix
+}
+
+func TestNetipTypes(t *testing.T) {
+	c := new(testNetipConfig)
+
+	for _, s := range Settings(c) {
+		if s.Default != "" {
+			t.Errorf("%s default: %q", s.Path, s.Default)
+		}
+	}
+
+	err := Read(strings.NewReader(`addr = "192.0.2.1"
+prefix = "192.0.2.0/24"
+allow = ["10.0.0.0/8", "fd00::/8"]
+`), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.Prefix.Contains(c.Addr) || c.Prefix.Bits() != 24 {
+		t.Error(c.Addr, c.Prefix)
+	}
+	if len(c.Allow) != 2 || c.Allow[1] != netip.MustParsePrefix("fd00::/8") {
+		t.Error(c.Allow)
+	}
+
+	if err := Assign(c, "prefix=192.0.2.1"); err == nil {
+		t.Error("prefix without bits")
+	}
+	if err := Assign(c, "prefix="); err != nil || c.Prefix.IsValid() {
+		t.Error(err, c.Prefix)
+	}
+
+	MustAssign(c, "prefix=2001:db8::/32")
+
+	b := bytes.NewBuffer(nil)
+	if err := Write(b, c); err != nil {
+		t.Fatal(err)
+	}
+	c2 := new(testNetipConfig)
+	if err := Read(bytes.NewReader(b.Bytes()), c2); err != nil {
+		t.Fatal(err, b)
+	}
+	if changes := Diff(c, c2); len(changes) != 0 {
+		t.Error(changes)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bytes"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testTypesConfig struct {
+	Endpoint *url.URL
+	Addr     net.IP
+	Network  net.IPNet
+	Allow    []*net.IPNet
+	Listen   Address
+	Mode     os.FileMode
+	Cache    ByteSize
+}
+
+func TestBuiltinTypes(t *testing.T) {
+	c := &testTypesConfig{
+		Listen: ":8080",
+		Mode:   0644,
+		Cache:  64 << 20,
+	}
+
+	var defaults []string
+	for _, s := range Settings(c) {
+		defaults = append(defaults, s.Path+" "+s.Default)
+	}
+	if !reflect.DeepEqual(defaults, []string{
+		"endpoint ",
+		"addr ",
+		"network ",
+		"allow ",
+		"listen :8080",
+		"mode 0644",
+		"cache 64MiB",
+	}) {
+		t.Error(defaults)
+	}
+
+	err := Read(strings.NewReader(`endpoint = "https://example.net/api?x=1"
+addr = "192.0.2.1"
+network = "192.0.2.0/24"
+allow = ["10.0.0.0/8", "fd00::/8"]
+listen = "[::1]:443"
+mode = "0600"
+cache = "1.5GB"
+`), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Endpoint.Host != "example.net" || c.Endpoint.Query().Get("x") != "1" {
+		t.Error(c.Endpoint)
+	}
+	if !c.Addr.Equal(net.IPv4(192, 0, 2, 1)) {
+		t.Error(c.Addr)
+	}
+	if c.Network.String() != "192.0.2.0/24" || !c.Network.Contains(c.Addr) {
+		t.Error(c.Network)
+	}
+	if len(c.Allow) != 2 || c.Allow[1].String() != "fd00::/8" {
+		t.Error(c.Allow)
+	}
+	if c.Listen.Host() != "::1" || c.Listen.Port() != 443 {
+		t.Error(c.Listen)
+	}
+	if c.Mode != 0600 {
+		t.Error(c.Mode)
+	}
+	if c.Cache != 1500000000 {
+		t.Error(c.Cache)
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := Write(b, c); err != nil {
+		t.Fatal(err)
+	}
+	c2 := new(testTypesConfig)
+	if err := Read(bytes.NewReader(b.Bytes()), c2); err != nil {
+		t.Fatal(err, b)
+	}
+	if changes := Diff(c, c2); len(changes) != 0 {
+		t.Error(changes)
+	}
+
+	for _, expr := range []string{
+		"endpoint=%zz",
+		"network=192.0.2.1",
+		"listen=localhost",
+		"listen=localhost:http",
+		"listen=localhost:65536",
+		"mode=0888",
+		"cache=1XB",
+		"cache=20EiB",
+	} {
+		if err := Assign(c, expr); err == nil {
+			t.Errorf("%s: no error", expr)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	for repr, n := range map[string]ByteSize{
+		"":        0,
+		"0":       0,
+		"1000":    1000,
+		"1024":    1024,
+		"2k":      2000,
+		"2 KiB":   2048,
+		"64mib":   64 << 20,
+		"64MiB":   64 << 20,
+		"0.5GiB":  1 << 29,
+		"3TB":     3e12,
+		"1.1kB":   1100,
+		"16EiB":   0, // Overflow.
+		"0.1":     0, // Fraction of a byte.
+		"1.0001k": 0,
+		"x":       0,
+	} {
+		var x ByteSize
+		err := x.UnmarshalText([]byte(repr))
+		if n == 0 && repr != "0" && repr != "" {
+			if err == nil {
+				t.Errorf("%s: no error", repr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", repr, err)
+		} else if x != n {
+			t.Errorf("%s: %d", repr, x)
+		}
+	}
+
+	for n, repr := range map[ByteSize]string{
+		0:        "0",
+		1:        "1",
+		1000:     "1kB",
+		1024:     "1KiB",
+		1536:     "1536",
+		64 << 20: "64MiB",
+		3e12:     "3TB",
+	} {
+		if s := n.String(); s != repr {
+			t.Errorf("%d: %s", n, s)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator may be implemented by configuration structs.  Validate is called
+// by the Validate function after the struct's fields have been validated.
+type Validator interface {
+	Validate() error
+}
+
+// Validate the configuration.  Field values are checked according to their
+// struct tags:
+//
+//	required:"true"    value must not be zero
+//	min:"1"            minimum number, duration, or string or slice length
+//	max:"65535"        maximum number, duration, or string or slice length
+//	oneof:"a b c"      value must be one of the space-separated options
+//	pattern:"^[a-z]+$" string (or each string slice item) must match
+//
+// The other tags are not checked for zero values, unless the value is
+// required.
+//
+// Structs which implement Validator are validated bottom-up, i.e. nested
+// structs before their parents.  The Validate method of an embedded struct is
+// not called separately if the embedding struct implements Validator, as it is
+// either promoted or shadowed.  Errors are prefixed with the dotted path.
+//
+// The tags other than "required" are also checked whenever a field is set by
+// this package.  Read, ReadFile and Assign validate the configuration
+// afterwards.  Buffer.Flush validates it after applying all files and
+// assignments, and Holder validates each update before publishing it.
+func Validate(config interface{}) (err error) {
+	defer func() {
+		err = asError(recover())
+	}()
+
+	validateContainer("", reflect.ValueOf(config))
+	return
+}
+
+func validateContainer(path string, node reflect.Value) {
+	if node.Kind() == reflect.Interface {
+		node = node.Elem()
+	}
+	if node.Kind() == reflect.Ptr {
+		if node.IsNil() {
+			return
+		}
+		node = node.Elem()
+	}
+
+	switch node.Kind() {
+	case reflect.Map:
+		for _, key := range reflectMapKeyStrings(node) {
+			k := key
+			if strings.Contains(k, ".") {
+				k = fmt.Sprintf("%q", k)
+			}
+			validateContainer(joinPath(path, k), node.MapIndex(reflect.ValueOf(key)))
+		}
+
+	case reflect.Slice:
+		if node.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < node.Len(); i++ {
+				validateContainer(joinPath(path, strconv.Itoa(i)), node.Index(i))
+			}
+		}
+
+	case reflect.Struct:
+		validateStruct(path, node, true)
+	}
+}
+
+// validateStruct fields, and call the Validator hook if hook is true.
+func validateStruct(path string, node reflect.Value, hook bool) {
+	var x interface{}
+	if node.CanAddr() {
+		x = node.Addr().Interface()
+	} else {
+		x = node.Interface()
+	}
+
+	v, hasValidator := x.(Validator)
+
+	for i := 0; i < node.Type().NumField(); i++ {
+		value := node.Field(i)
+		if !value.CanInterface() {
+			continue
+		}
+
+		field := node.Type().Field(i)
+
+		key, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+
+		p := path
+		if key != "" {
+			p = joinPath(path, key)
+		}
+
+		kind := indirectType(field.Type).Kind()
+		if isCustomType(field.Type) {
+			kind = reflect.Invalid // Validated as a value.
+		}
+
+		switch kind {
+		case reflect.Map, reflect.Struct:
+			if key == "" && hasValidator {
+				// Validate method is promoted or shadowed.
+				validateEmbedded(p, value)
+			} else {
+				validateContainer(p, value)
+			}
+
+		case reflect.Slice:
+			validateContainer(p, value)
+			fallthrough
+
+		default:
+			if value.IsZero() {
+				if field.Tag.Get("required") == "true" {
+					panic(&Error{Path: p, Err: errors.New("value is required")})
+				}
+				break // Unset optional value.
+			}
+			if err := checkValue(value, field.Tag); err != nil {
+				panic(&Error{Path: p, Err: err})
+			}
+		}
+	}
+
+	if hook && hasValidator {
+		if err := v.Validate(); err != nil {
+			if path == "" {
+				panic(err)
+			}
+			panic(&Error{Path: path, Err: err})
+		}
+	}
+}
+
+// validateEmbedded struct without calling its Validator hook.
+func validateEmbedded(path string, node reflect.Value) {
+	if node.Kind() == reflect.Ptr {
+		if node.IsNil() {
+			return
+		}
+		node = node.Elem()
+	}
+
+	validateStruct(path, node, false)
+}
+
+// checkValue against the min, max, oneof and pattern tags.
+func checkValue(value reflect.Value, tag reflect.StructTag) error {
+	if s, found := tag.Lookup("min"); found {
+		if err := checkBound(value, s, -1); err != nil {
+			return err
+		}
+	}
+
+	if s, found := tag.Lookup("max"); found {
+		if err := checkBound(value, s, 1); err != nil {
+			return err
+		}
+	}
+
+	if s, found := tag.Lookup("oneof"); found {
+		repr := fmt.Sprint(value.Interface())
+		if value.Kind() == reflect.String {
+			repr = value.String()
+		}
+		ok := false
+		for _, option := range strings.Fields(s) {
+			if option == repr {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("value %q is not one of: %s", redact(value, repr), strings.Join(strings.Fields(s), ", "))
+		}
+	}
+
+	if s, found := tag.Lookup("pattern"); found {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return fmt.Errorf("invalid pattern tag: %v", err)
+		}
+
+		var strs []string
+		switch value.Kind() {
+		case reflect.String:
+			strs = []string{value.String()}
+
+		case reflect.Slice:
+			if value.Type().Elem().Kind() == reflect.String {
+				strs = value.Interface().([]string)
+			}
+		}
+
+		for _, s := range strs {
+			if !re.MatchString(s) {
+				return fmt.Errorf("value %q does not match pattern %q", redact(value, s), re)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkBound compares the value with the bound.  The value must not be on the
+// side of the bound indicated by the sign of direction.
+func checkBound(value reflect.Value, bound string, direction int) (err error) {
+	var cmp int
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var b int64
+		if value.Type() == durationType {
+			var d time.Duration
+			d, err = time.ParseDuration(bound)
+			b = int64(d)
+		} else {
+			b, err = strconv.ParseInt(bound, 10, 64)
+		}
+		cmp = compareInts(value.Int(), b)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var b uint64
+		b, err = strconv.ParseUint(bound, 10, 64)
+		switch x := value.Uint(); {
+		case x < b:
+			cmp = -1
+		case x > b:
+			cmp = 1
+		}
+
+	case reflect.Float32, reflect.Float64:
+		var b float64
+		b, err = strconv.ParseFloat(bound, 64)
+		switch x := value.Float(); {
+		case x < b:
+			cmp = -1
+		case x > b:
+			cmp = 1
+		}
+
+	case reflect.String, reflect.Slice:
+		var b int64
+		b, err = strconv.ParseInt(bound, 10, 64)
+		cmp = compareInts(int64(value.Len()), b)
+		if err == nil && cmp == direction {
+			if direction < 0 {
+				return fmt.Errorf("length %d is less than %s", value.Len(), bound)
+			}
+			return fmt.Errorf("length %d is greater than %s", value.Len(), bound)
+		}
+
+	default:
+		return errors.New("min and max tags are not supported for the field type")
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid bound %q: %v", bound, err)
+	}
+
+	if cmp == direction {
+		if direction < 0 {
+			return fmt.Errorf("value %v is less than %s", value.Interface(), bound)
+		}
+		return fmt.Errorf("value %v is greater than %s", value.Interface(), bound)
+	}
+
+	return nil
+}
+
+func compareInts(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
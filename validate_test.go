@@ -0,0 +1,206 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testValidConfig struct {
+	Name    string        `required:"true" pattern:"^[a-z]+$"`
+	Port    uint16        `min:"1024"`
+	Level   string        `oneof:"debug info error"`
+	Ratio   float64       `min:"0" max:"1"`
+	Timeout time.Duration `max:"1m"`
+	Tags    []string      `max:"2" pattern:"^#"`
+
+	Server testValidServer
+	Items  []testValidServer
+}
+
+type testValidServer struct {
+	Host string
+	Port int `min:"1" max:"65535"`
+}
+
+func (s *testValidServer) Validate() error {
+	if s.Host == "" && s.Port != 0 {
+		return errors.New("port without host")
+	}
+	return nil
+}
+
+type TestValidEmbed struct {
+	Calls int
+}
+
+func (e *TestValidEmbed) Validate() error {
+	e.Calls++
+	return nil
+}
+
+type testValidPromoted struct {
+	TestValidEmbed
+}
+
+type testValidShadowed struct {
+	TestValidEmbed
+	Shadowed int
+}
+
+func (s *testValidShadowed) Validate() error {
+	s.Shadowed++
+	return nil
+}
+
+type testValidNested struct {
+	Embed struct {
+		TestValidEmbed
+	}
+}
+
+func newTestValidConfig() *testValidConfig {
+	c := new(testValidConfig)
+	c.Name = "test"
+	c.Port = 8080
+	c.Level = "info"
+	c.Server = testValidServer{"localhost", 80}
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(newTestValidConfig()); err != nil {
+		t.Error(err)
+	}
+
+	for _, spec := range []struct {
+		expr string
+		err  string
+	}{
+		{"port=80", "port: value 80 is less than 1024"},
+		{"level=trace", `level: value "trace" is not one of: debug, info, error`},
+		{"ratio=1.5", "ratio: value 1.5 is greater than 1"},
+		{"timeout=1h", "timeout: value 1h0m0s is greater than 1m"},
+		{`tags=["#a", "#b", "#c"]`, "tags: length 3 is greater than 2"},
+		{"tags=a", `tags: value "a" does not match pattern "^#"`},
+		{"name=Test", `name: value "Test" does not match pattern "^[a-z]+$"`},
+		{"server.port=0", "server.port: value 0 is less than 1"},
+		{"items.0.port=70000", "items.0.port: value 70000 is greater than 65535"},
+	} {
+		c := newTestValidConfig()
+		if err := Assign(c, spec.expr); err == nil {
+			t.Errorf("%s: no error", spec.expr)
+		} else if err.Error() != spec.err {
+			t.Errorf("%s: %v", spec.expr, err)
+		}
+	}
+
+	c := newTestValidConfig()
+	if err := Read(strings.NewReader("port = 1\n"), c); err == nil {
+		t.Error("Read")
+	}
+	if c.Port != 8080 {
+		t.Error(c.Port)
+	}
+
+	c = newTestValidConfig()
+	c.Name = ""
+	if err := Validate(c); err == nil || err.Error() != "name: value is required" {
+		t.Error(err)
+	}
+
+	c = newTestValidConfig()
+	c.Server.Host = ""
+	if err := Validate(c); err == nil || err.Error() != "server: port without host" {
+		t.Error(err)
+	}
+
+	c = newTestValidConfig()
+	c.Items = []testValidServer{{"localhost", 80}, {"", 80}}
+	if err := Validate(c); err == nil || err.Error() != "items.1: port without host" {
+		t.Error(err)
+	}
+}
+
+func TestValidateEmbedded(t *testing.T) {
+	promoted := new(testValidPromoted)
+	if err := Validate(promoted); err != nil {
+		t.Fatal(err)
+	}
+	if promoted.Calls != 1 {
+		t.Error("promoted:", promoted.Calls)
+	}
+
+	shadowed := new(testValidShadowed)
+	if err := Validate(shadowed); err != nil {
+		t.Fatal(err)
+	}
+	if shadowed.Calls != 0 || shadowed.Shadowed != 1 {
+		t.Error("shadowed:", shadowed.Calls, shadowed.Shadowed)
+	}
+
+	nested := new(testValidNested)
+	if err := Validate(nested); err != nil {
+		t.Fatal(err)
+	}
+	if nested.Embed.Calls != 1 {
+		t.Error("nested:", nested.Embed.Calls)
+	}
+}
+
+func TestValidateImplied(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "server.toml")
+	if err := ioutil.WriteFile(filename, []byte("[server]\nhost = \"\"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Assign(newTestValidConfig(), "server.host="); err == nil || err.Error() != "server: port without host" {
+		t.Error("Assign:", err)
+	}
+	if err := Read(strings.NewReader("[server]\nhost = \"\"\n"), newTestValidConfig()); err == nil || err.Error() != "server: port without host" {
+		t.Error("Read:", err)
+	}
+	if err := ReadFile(filename, newTestValidConfig()); err == nil || err.Error() != "server: port without host" {
+		t.Error("ReadFile:", err)
+	}
+	if err := ReadFileIfExists(path.Join(dir, "nonexistent.toml"), newTestValidConfig()); err != nil {
+		t.Error("ReadFileIfExists:", err)
+	}
+
+	c := new(testValidConfig)
+	if err := Assign(c, "level=debug"); err == nil || err.Error() != "name: value is required" {
+		t.Error("Assign required:", err)
+	}
+	if c.Level != "debug" {
+		t.Error(c.Level)
+	}
+}
+
+func TestBufferValidate(t *testing.T) {
+	b := NewBuffer()
+	b.Assigner().Set("server.host=")
+
+	if err := b.Apply(newTestValidConfig()); err == nil || err.Error() != "server: port without host" {
+		t.Error(err)
+	}
+
+	b.Assigner().Set("server.host=example.net")
+
+	if err := b.Apply(newTestValidConfig()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchDelay gives writers some time to finish after a notification.
+const watchDelay = 100 * time.Millisecond
+
+// Watcher reloads configuration when the files of a Buffer change.
+type Watcher struct {
+	buffer        *Buffer
+	newConfig     func() interface{}
+	ignoreUnknown bool
+
+	mu          sync.Mutex
+	subscribers []func(config interface{})
+	errHandlers []func(error)
+	includes    []string // Patterns of the files included by the last flush.
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewWatcher for the files and directories of a buffer.  Each time a change is
+// detected, newConfig is called to create a configuration object with default
+// values, and the buffer is flushed into it (including assignments).  If
+// flushing and validation succeed, the object is delivered to subscribers.
+// Unknown keys are silently skipped if ignoreUnknown is true.
+func NewWatcher(b *Buffer, newConfig func() interface{}, ignoreUnknown bool) *Watcher {
+	return &Watcher{
+		buffer:        b,
+		newConfig:     newConfig,
+		ignoreUnknown: ignoreUnknown,
+	}
+}
+
+// Subscribe to reloaded configuration objects.  The function is called
+// synchronously in the watcher's goroutine.
+func (w *Watcher) Subscribe(f func(config interface{})) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, f)
+}
+
+// OnError registers a function which receives reload errors.
+func (w *Watcher) OnError(f func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.errHandlers = append(w.errHandlers, f)
+}
+
+// Start watching in a background goroutine.  File system notifications are
+// used when available; the files are also polled at the given interval.  If
+// the interval is not positive, the files are not polled.  The buffer must not
+// be modified after this.
+//
+// Files included by TOML files are watched too.  The buffer is flushed once
+// into a discarded configuration object to discover them.
+func (w *Watcher) Start(interval time.Duration) {
+	if w.done != nil {
+		panic("watcher already started")
+	}
+	w.done = make(chan struct{})
+
+	last := w.fingerprint()
+	w.flush()
+	last = w.refingerprint(last)
+
+	dirs := w.dirs()
+	notify, stopNotify := watchDirs(dirs)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() { stopNotify() }()
+
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			// Also detects changes made during the initial flush.
+			if fp := w.fingerprint(); !equalFingerprints(fp, last) {
+				w.Reload()
+				last = w.refingerprint(fp)
+
+				if d := w.dirs(); strings.Join(d, "\n") != strings.Join(dirs, "\n") {
+					stopNotify()
+					dirs = d
+					notify, stopNotify = watchDirs(dirs)
+				}
+			}
+
+			select {
+			case <-w.done:
+				return
+
+			case <-tick:
+
+			case <-notify:
+				select {
+				case <-w.done:
+					return
+				case <-time.After(watchDelay):
+				}
+			}
+		}
+	}()
+}
+
+// Close stops watching.  It waits for an ongoing reload to finish.  It may be
+// called more than once.
+func (w *Watcher) Close() {
+	if w.done != nil {
+		w.closeOnce.Do(func() { close(w.done) })
+		w.wg.Wait()
+	}
+}
+
+// Reload the configuration immediately, and deliver it to subscribers or
+// error handlers.
+func (w *Watcher) Reload() {
+	config, err := w.flush()
+
+	w.mu.Lock()
+	subscribers := w.subscribers
+	errHandlers := w.errHandlers
+	w.mu.Unlock()
+
+	if err != nil {
+		for _, f := range errHandlers {
+			f(err)
+		}
+		return
+	}
+
+	for _, f := range subscribers {
+		f(config)
+	}
+}
+
+// flush the buffer into a new configuration object, and remember the files
+// included by it.
+func (w *Watcher) flush() (config interface{}, err error) {
+	var includes []string
+
+	config = w.newConfig()
+	restore := observeIncludes(config, func(pattern string) {
+		includes = append(includes, pattern)
+	})
+	err = w.buffer.Flush(config, w.ignoreUnknown)
+	restore()
+
+	w.mu.Lock()
+	w.includes = includes
+	w.mu.Unlock()
+	return
+}
+
+// patterns of the included files.
+func (w *Watcher) includePatterns() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.includes
+}
+
+// filenames of the buffered and included files and the current glob matches.
+func (w *Watcher) filenames() (names []string) {
+	for _, entry := range w.buffer.list {
+		switch {
+		case entry.filename != "":
+			names = append(names, entry.filename)
+
+		case entry.pattern != "":
+			matches, _ := filepath.Glob(entry.pattern)
+			names = append(names, matches...)
+		}
+	}
+
+	for _, pattern := range w.includePatterns() {
+		matches, _ := filepath.Glob(pattern)
+		names = append(names, matches...)
+	}
+	return
+}
+
+// dirs containing the buffered and included files and glob patterns.
+func (w *Watcher) dirs() []string {
+	set := make(map[string]struct{})
+
+	for _, entry := range w.buffer.list {
+		var dir string
+
+		switch {
+		case entry.filename != "":
+			dir = filepath.Dir(entry.filename)
+
+		case entry.pattern != "":
+			dir = filepath.Dir(entry.pattern)
+			if strings.ContainsAny(dir, `*?[\`) {
+				continue
+			}
+
+		default:
+			continue
+		}
+
+		set[dir] = struct{}{}
+	}
+
+	for _, pattern := range w.includePatterns() {
+		if dir := filepath.Dir(pattern); !strings.ContainsAny(dir, `*?[\`) {
+			set[dir] = struct{}{}
+		}
+	}
+
+	var dirs []string
+	for dir := range set {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// fingerprint of the buffered and included files' sizes and modification
+// times by filename.
+func (w *Watcher) fingerprint() map[string]string {
+	return w.refingerprint(nil)
+}
+
+// refingerprint after a flush.  Files which are in the old fingerprint keep
+// their state, so that changes made during the flush are detected later.
+// Files which were included by the flush are fingerprinted now.
+func (w *Watcher) refingerprint(old map[string]string) map[string]string {
+	fp := make(map[string]string)
+
+	for _, name := range w.filenames() {
+		if state, found := old[name]; found {
+			fp[name] = state
+		} else if info, err := os.Stat(name); err == nil {
+			fp[name] = fmt.Sprintf("%d\x00%d", info.Size(), info.ModTime().UnixNano())
+		} else {
+			fp[name] = ""
+		}
+	}
+
+	return fp
+}
+
+func equalFingerprints(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, state := range a {
+		if other, found := b[name]; !found || other != state {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package confi
+
+import (
+	"os"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// watchDirs using inotify.  The returned channel receives a value after
+// changes in the directories.  Nonexistent directories are ignored.  A nil
+// channel is returned if inotify is not available.
+func watchDirs(dirs []string) (notify <-chan struct{}, stop func()) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, func() {}
+	}
+
+	// Non-blocking file is managed by the runtime poller, so closing it
+	// interrupts Read.
+	f := os.NewFile(uintptr(fd), "inotify")
+
+	for _, dir := range dirs {
+		syscall.InotifyAddWatch(fd, dir, inotifyMask)
+	}
+
+	c := make(chan struct{}, 1)
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			if _, err := f.Read(buf); err != nil {
+				return
+			}
+
+			select {
+			case c <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return c, func() { f.Close() }
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package confi
+
+// watchDirs is not supported; the watcher relies on polling.
+func watchDirs(dirs []string) (notify <-chan struct{}, stop func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "a.toml")
+	if err := ioutil.WriteFile(filename, []byte("bar = 1\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuffer()
+	b.FileReader().Set(filename)
+	b.DirReader("*.json").Set(dir)
+	b.Assigner().Set("foo.key2=2")
+
+	configs := make(chan *testConfig, 10)
+	errors := make(chan error, 10)
+
+	w := NewWatcher(b, func() interface{} { return newTestConfig() }, false)
+	w.Subscribe(func(config interface{}) { configs <- config.(*testConfig) })
+	w.OnError(func(err error) { errors <- err })
+	w.Start(50 * time.Millisecond)
+	defer w.Close()
+
+	time.Sleep(20 * time.Millisecond) // Distinct modification time.
+
+	if err := ioutil.WriteFile(filename, []byte("bar = 12345\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case c := <-configs:
+		if c.Bar != 12345 || c.Foo.Key2 != 2 {
+			t.Error(c.Bar, c.Foo.Key2)
+		}
+	case err := <-errors:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	if err := ioutil.WriteFile(path.Join(dir, "b.json"), []byte(`{"baz": {"sample_rate": 48000}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case c := <-configs:
+		if c.Bar != 12345 || c.Baz.SampleRate != 48000 {
+			t.Error(c.Bar, c.Baz.SampleRate)
+		}
+	case err := <-errors:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	if err := ioutil.WriteFile(filename, []byte("bar = \"x\"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case c := <-configs:
+		t.Error(c)
+	case err := <-errors:
+		t.Log(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestWatcherInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(path.Join(dir, "inc"), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := path.Join(dir, "a.toml")
+	if err := ioutil.WriteFile(filename, []byte("include = \"inc/b.toml\"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	included := path.Join(dir, "inc", "b.toml")
+	if err := ioutil.WriteFile(included, []byte("bar = 1\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuffer()
+	b.FileReader().Set(filename)
+
+	configs := make(chan *testConfig, 10)
+	errors := make(chan error, 10)
+
+	w := NewWatcher(b, func() interface{} { return newTestConfig() }, false)
+	w.Subscribe(func(config interface{}) { configs <- config.(*testConfig) })
+	w.OnError(func(err error) { errors <- err })
+	w.Start(50 * time.Millisecond)
+	defer w.Close()
+
+	time.Sleep(20 * time.Millisecond) // Distinct modification time.
+
+	if err := ioutil.WriteFile(included, []byte("bar = 12345\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case c := <-configs:
+		if c.Bar != 12345 {
+			t.Error(c.Bar)
+		}
+	case err := <-errors:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestWatcherChangeDuringReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "a.toml")
+	if err := ioutil.WriteFile(filename, []byte("bar = 1\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuffer()
+	b.FileReader().Set(filename)
+
+	configs := make(chan *testConfig, 10)
+
+	w := NewWatcher(b, func() interface{} { return newTestConfig() }, false)
+	w.Subscribe(func(config interface{}) {
+		c := config.(*testConfig)
+		if c.Bar == 12345 {
+			// Written after the file was read by the reload.
+			if err := ioutil.WriteFile(filename, []byte("bar = 2\n"), 0666); err != nil {
+				t.Error(err)
+			}
+		}
+		configs <- c
+	})
+	w.Start(50 * time.Millisecond)
+	defer w.Close()
+
+	time.Sleep(20 * time.Millisecond) // Distinct modification time.
+
+	if err := ioutil.WriteFile(filename, []byte("bar = 12345\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expect := range []int{12345, 2} {
+		select {
+		case c := <-configs:
+			if c.Bar != expect {
+				t.Error(c.Bar)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+}
+
+func TestWatcherNotifyOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := path.Join(dir, "a.toml")
+	if err := ioutil.WriteFile(filename, []byte("bar = 1\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBuffer()
+	b.FileReader().Set(filename)
+
+	configs := make(chan *testConfig, 10)
+
+	w := NewWatcher(b, func() interface{} { return newTestConfig() }, false)
+	w.Subscribe(func(config interface{}) { configs <- config.(*testConfig) })
+	w.Start(0)
+	defer w.Close()
+
+	if runtime.GOOS == "linux" {
+		time.Sleep(20 * time.Millisecond) // Distinct modification time.
+
+		if err := ioutil.WriteFile(filename, []byte("bar = 12345\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case c := <-configs:
+			if c.Bar != 12345 {
+				t.Error(c.Bar)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	w.Close()
+	w.Close()
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReadYAML into the configuration.  The document must be a mapping.
+// Sequences of mappings are appended to struct slices, like TOML table arrays.
+func ReadYAML(r io.Reader, config interface{}) error {
+	return readYAML(r, config, false)
+}
+
+func readYAML(r io.Reader, config interface{}, ignoreUnknown bool) (err error) {
+	var doc yaml.Node
+
+	if err = yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			err = nil // Empty document.
+		}
+		return
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errors.New("YAML document is not a mapping")
+	}
+
+	defer func() {
+		err = asError(recover())
+	}()
+
+	setYAMLFields(config, "", root, ignoreUnknown)
+	return
+}
+
+// ReadYAMLFile containing YAML into the configuration.
+func ReadYAMLFile(filename string, config interface{}) error {
+	return readYAMLFile(filename, config, false)
+}
+
+func readYAMLFile(filename string, config interface{}, ignoreUnknown bool) (err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	return readYAML(f, config, ignoreUnknown)
+}
+
+func setYAMLFields(config interface{}, path string, mapping *yaml.Node, ignoreUnknown bool) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k := mapping.Content[i].Value
+		v := resolveYAMLAlias(mapping.Content[i+1])
+
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+
+		switch v.Kind {
+		case yaml.ScalarNode:
+			if v.Tag == "!!null" {
+				panic(fmt.Errorf("%s: null value not supported", p))
+			}
+			setFromString(config, p, v.Value, ignoreUnknown)
+
+		case yaml.SequenceNode:
+			if yamlMappings(v) {
+				appendYAMLMappings(config, p, v.Content, ignoreUnknown)
+			} else if len(v.Content) == 0 && isStructSlice(config, p) {
+				// Nothing to append.
+			} else {
+				setFromString(config, p, yamlScalarArray(p, v), ignoreUnknown)
+			}
+
+		case yaml.MappingNode:
+			setYAMLFields(config, p, v, ignoreUnknown)
+
+		default:
+			panic(fmt.Errorf("%s: value type not supported", p))
+		}
+	}
+}
+
+func resolveYAMLAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func yamlMappings(seq *yaml.Node) bool {
+	if len(seq.Content) == 0 {
+		return false
+	}
+
+	for _, item := range seq.Content {
+		if resolveYAMLAlias(item).Kind != yaml.MappingNode {
+			return false
+		}
+	}
+
+	return true
+}
+
+// yamlScalarArray converts a sequence of scalars to JSON array representation.
+func yamlScalarArray(path string, seq *yaml.Node) string {
+	items := []string{}
+
+	for _, item := range seq.Content {
+		item = resolveYAMLAlias(item)
+		if item.Kind != yaml.ScalarNode {
+			panic(fmt.Errorf("%s: sequence item type not supported", path))
+		}
+		items = append(items, item.Value)
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		panic(fmt.Errorf("%s: %v", path, err))
+	}
+	return string(data)
+}
+
+func appendYAMLMappings(config interface{}, path string, mappings []*yaml.Node, ignoreUnknown bool) {
+	node := lookup(config, path)
+	n := node.Len()
+	for i, x := range mappings {
+		setYAMLFields(config, fmt.Sprintf("%s.%d", path, n+i), resolveYAMLAlias(x), ignoreUnknown)
+	}
+}
+
+// WriteYAML writes the configuration as YAML.
+func WriteYAML(w io.Writer, config interface{}) error {
+	e := yaml.NewEncoder(w)
+	e.SetIndent(2)
+	if err := e.Encode(sanitizeContainer(make(map[string]interface{}), reflect.ValueOf(config).Elem())); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// WriteYAMLFile containing the configuration as YAML.
+func WriteYAMLFile(filename string, config interface{}) (err error) {
+	b := bytes.NewBuffer(nil)
+
+	if err = WriteYAML(b, config); err != nil {
+		return
+	}
+
+	return ioutil.WriteFile(filename, b.Bytes(), 0666)
+}
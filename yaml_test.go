@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package confi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testConfigYAML = `bar: 12345
+baz:
+  embed1:
+    embedded: false
+  embed2:
+    embedded: false
+  embedded: false
+  interval: 10h9m8.007006005s
+  quux:
+    key_a: "true"
+    key_b: true
+  sample_rate: 48000
+ext:
+  a:
+    average: 123
+  b:
+    beverage: 456
+foo:
+  key1: true
+  key2: -10
+  key2b: -128
+  key3: -11
+  key3a: -32768
+  key4: -100000000000000
+  key5: 10
+  key5b: 255
+  key6: 11
+  key6a: 65535
+  key7: 100000000000000
+  key8: 1.5
+  key9: 1.0000000000005
+  key10: hello, world
+  key11:
+    - hello
+    - world
+`
+
+func TestReadYAML(t *testing.T) {
+	c := newTestConfig()
+
+	if err := ReadYAML(strings.NewReader(testConfigYAML), c); err != nil {
+		t.Fatal(err)
+	}
+
+	testConfigValues(t, c)
+}
+
+func TestReadYAMLSlice(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	var c struct {
+		Items []item
+	}
+	c.Items = []item{{"first"}}
+
+	if err := ReadYAML(strings.NewReader(`items:
+  - name: second
+  - name: third
+`), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Items) != 3 || c.Items[0].Name != "first" || c.Items[1].Name != "second" || c.Items[2].Name != "third" {
+		t.Error(c.Items)
+	}
+
+	if err := ReadYAML(strings.NewReader("items: []\n"), &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Items) != 3 {
+		t.Error(c.Items)
+	}
+}
+
+func TestReadYAMLError(t *testing.T) {
+	for _, doc := range []string{
+		"- 1\n",
+		"bar: x\n",
+		"bar: null\n",
+		"nonexistent: 1\n",
+		"foo:\n  key11:\n    - [nested]\n",
+	} {
+		if err := ReadYAML(strings.NewReader(doc), newTestConfig()); err == nil {
+			t.Error(doc)
+		}
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	c := newTestConfig()
+
+	if err := Read(strings.NewReader(testConfigTOML), c); err != nil {
+		t.Fatal(err)
+	}
+
+	b := new(bytes.Buffer)
+
+	if err := WriteYAML(b, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); s != testConfigYAML {
+		t.Error(s)
+	}
+}